@@ -0,0 +1,184 @@
+/*
+Copyright 2019 Replicated, Inc..
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package yamlnode has the go.yaml.in/yaml/v3 helpers shared by the HelmChart YAML hooks of
+// the kots API versions.
+package yamlnode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.yaml.in/yaml/v3"
+)
+
+// Comments holds the comments yaml.v3 attaches to a node
+type Comments struct {
+	head string
+	line string
+	foot string
+}
+
+func CommentsFromNode(node *yaml.Node) Comments {
+	return Comments{
+		head: node.HeadComment,
+		line: node.LineComment,
+		foot: node.FootComment,
+	}
+}
+
+func (c Comments) ApplyTo(node *yaml.Node) {
+	node.HeadComment = c.head
+	node.LineComment = c.line
+	node.FootComment = c.foot
+}
+
+// OrderedKeys returns keys in the recorded order, followed by any keys with no recorded
+// order, sorted
+func OrderedKeys(order []string, keys []string) []string {
+	present := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		present[k] = true
+	}
+
+	result := make([]string, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, k := range order {
+		if present[k] && !seen[k] {
+			result = append(result, k)
+			seen[k] = true
+		}
+	}
+
+	remaining := []string{}
+	for _, k := range keys {
+		if !seen[k] {
+			remaining = append(remaining, k)
+		}
+	}
+	sort.Strings(remaining)
+
+	return append(result, remaining...)
+}
+
+// UnmarshalAsJSON decodes a yaml node by converting it to JSON first, so that the
+// JSON hooks and tags of the target type are used
+func UnmarshalAsJSON(node *yaml.Node, target interface{}) error {
+	var raw interface{}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(jsonCompatible(raw))
+	if err != nil {
+		return errors.Wrap(err, "failed to convert yaml to json")
+	}
+
+	return json.Unmarshal(data, target)
+}
+
+// jsonCompatible converts the map[interface{}]interface{} values yaml.v3 produces for mappings
+// with non-string keys into map[string]interface{}
+func jsonCompatible(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for k, v := range typed {
+			typed[k] = jsonCompatible(v)
+		}
+		return typed
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			result[fmt.Sprint(k)] = jsonCompatible(v)
+		}
+		return result
+	case []interface{}:
+		for i, v := range typed {
+			typed[i] = jsonCompatible(v)
+		}
+		return typed
+	default:
+		return value
+	}
+}
+
+func Resolve(node *yaml.Node) *yaml.Node {
+	for node != nil {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			if len(node.Content) == 0 {
+				return node
+			}
+			node = node.Content[0]
+		case yaml.AliasNode:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+	return node
+}
+
+func MappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func HasMergeKey(node *yaml.Node) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Tag == "!!merge" {
+			return true
+		}
+	}
+	return false
+}
+
+// NumberLiteral returns the value of a scalar node when it is also a valid JSON number, so
+// that it can be kept as written. YAML-only forms such as 0x1F, 1_000 or .inf are not
+// returned.
+func NumberLiteral(node *yaml.Node) (json.Number, bool) {
+	if node.Kind != yaml.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") {
+		return "", false
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(node.Value))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return "", false
+	}
+	number, ok := value.(json.Number)
+	return number, ok
+}
+
+// NumberNode returns a scalar node that emits literal unchanged
+func NumberNode(literal json.Number) *yaml.Node {
+	tag := "!!float"
+	if _, err := literal.Int64(); err == nil {
+		tag = "!!int"
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: literal.String()}
+}
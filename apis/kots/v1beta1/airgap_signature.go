@@ -0,0 +1,204 @@
+package v1beta1
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	kotscrypto "github.com/replicatedhq/kotskinds/pkg/crypto"
+)
+
+// AirgapLicense is the part of a license needed to validate an airgap bundle.
+// licensewrapper.LicenseWrapper implements it for both v1beta1 and v1beta2 licenses.
+// +kubebuilder:object:generate=false
+type AirgapLicense interface {
+	GetAppSlug() string
+	GetChannelID() string
+	GetChannels() []Channel
+	GetSignature() []byte
+}
+
+// AirgapSignatureMissingError is returned when an airgap bundle has no signature
+// +kubebuilder:object:generate=false
+type AirgapSignatureMissingError struct{}
+
+func (e *AirgapSignatureMissingError) Error() string {
+	return "airgap signature not found"
+}
+
+func IsAirgapSignatureMissingError(err error) bool {
+	var asme *AirgapSignatureMissingError
+	return errors.As(err, &asme)
+}
+
+// AirgapSignatureInvalidError is returned when the airgap signature does not match the
+// bundle metadata, or was not made with the app key from the license
+// +kubebuilder:object:generate=false
+type AirgapSignatureInvalidError struct {
+	Err error
+}
+
+func (e *AirgapSignatureInvalidError) Error() string {
+	return fmt.Sprintf("airgap signature verification failed: %v", e.Err)
+}
+
+func (e *AirgapSignatureInvalidError) Unwrap() error {
+	return e.Err
+}
+
+func IsAirgapSignatureInvalidError(err error) bool {
+	var asie *AirgapSignatureInvalidError
+	return errors.As(err, &asie)
+}
+
+// AirgapAppSlugMismatchError is returned when an airgap bundle is for a different app than
+// the license
+// +kubebuilder:object:generate=false
+type AirgapAppSlugMismatchError struct {
+	AirgapAppSlug  string
+	LicenseAppSlug string
+}
+
+func (e *AirgapAppSlugMismatchError) Error() string {
+	return fmt.Sprintf("airgap bundle is for app %q, license is for app %q", e.AirgapAppSlug, e.LicenseAppSlug)
+}
+
+func IsAirgapAppSlugMismatchError(err error) bool {
+	var aasme *AirgapAppSlugMismatchError
+	return errors.As(err, &aasme)
+}
+
+// AirgapChannelMismatchError is returned when an airgap bundle is for a channel the license
+// is not assigned to
+// +kubebuilder:object:generate=false
+type AirgapChannelMismatchError struct {
+	AirgapChannelID   string
+	LicenseChannelIDs []string
+}
+
+func (e *AirgapChannelMismatchError) Error() string {
+	return fmt.Sprintf("airgap bundle is for channel %q, license is for channels %s", e.AirgapChannelID, strings.Join(e.LicenseChannelIDs, ", "))
+}
+
+func IsAirgapChannelMismatchError(err error) bool {
+	var acme *AirgapChannelMismatchError
+	return errors.As(err, &acme)
+}
+
+// AirgapSignature is the decoded spec.signature of an Airgap. Like a license signature, it
+// carries the exact bytes that were signed, so that verification does not depend on how the
+// manifest is encoded.
+// +kubebuilder:object:generate=false
+type AirgapSignature struct {
+	// AirgapData is the JSON encoded AirgapSpec that was signed, without a signature
+	AirgapData []byte `json:"airgapData"`
+	// Signature is the RSA-PSS signature of AirgapData, made with SHA-256 and the app key
+	Signature []byte `json:"signature"`
+}
+
+// ValidateSignature checks that the airgap metadata was signed with the app key embedded in
+// the license signature, and that the bundle is for the license's app and one of its
+// channels. spec.signature is a JSON encoded AirgapSignature. The signature is verified
+// over its AirgapData, which must then match the rest of the spec.
+//
+// The license signature itself is not validated here, see License.ValidateLicense.
+// Returns an *AirgapSignatureMissingError, *AirgapSignatureInvalidError,
+// *AirgapAppSlugMismatchError or *AirgapChannelMismatchError.
+func (a *Airgap) ValidateSignature(license AirgapLicense) error {
+	if len(a.Spec.Signature) == 0 {
+		return &AirgapSignatureMissingError{}
+	}
+
+	_, _, appKeys, err := kotscrypto.DecodeLicenseSignature(license.GetSignature())
+	if err != nil {
+		return errors.Wrap(err, "failed to decode license signature")
+	}
+
+	var signature AirgapSignature
+	if err := json.Unmarshal(a.Spec.Signature, &signature); err != nil {
+		return &AirgapSignatureInvalidError{Err: errors.Wrap(err, "failed to decode airgap signature")}
+	}
+
+	if err := kotscrypto.VerifySignatureWithKeyRSA(signature.AirgapData, signature.Signature, appKeys.PublicKeyRSA, crypto.SHA256); err != nil {
+		return &AirgapSignatureInvalidError{Err: err}
+	}
+
+	if err := a.Spec.compareSignedData(signature.AirgapData); err != nil {
+		return &AirgapSignatureInvalidError{Err: err}
+	}
+
+	if a.Spec.AppSlug != license.GetAppSlug() {
+		return &AirgapAppSlugMismatchError{AirgapAppSlug: a.Spec.AppSlug, LicenseAppSlug: license.GetAppSlug()}
+	}
+
+	channelIDs := []string{}
+	if channelID := license.GetChannelID(); channelID != "" {
+		channelIDs = append(channelIDs, channelID)
+	}
+	for _, channel := range license.GetChannels() {
+		if channel.ChannelID != license.GetChannelID() {
+			channelIDs = append(channelIDs, channel.ChannelID)
+		}
+	}
+	for _, channelID := range channelIDs {
+		if channelID == a.Spec.ChannelID {
+			return nil
+		}
+	}
+	return &AirgapChannelMismatchError{AirgapChannelID: a.Spec.ChannelID, LicenseChannelIDs: channelIDs}
+}
+
+// compareSignedData returns an error naming the first field of the spec, in sorted order,
+// that differs from the signed airgap data
+func (s AirgapSpec) compareSignedData(signedJSON []byte) error {
+	var signed AirgapSpec
+	if err := json.Unmarshal(signedJSON, &signed); err != nil {
+		return errors.Wrap(err, "failed to unmarshal signed airgap data")
+	}
+	s.Signature = nil
+	signed.Signature = nil
+
+	// the specs are compared as json so that empty and missing fields are the same
+	actualFields, err := jsonFields(s)
+	if err != nil {
+		return err
+	}
+	signedFields, err := jsonFields(signed)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for name := range actualFields {
+		names = append(names, name)
+	}
+	for name := range signedFields {
+		if _, ok := actualFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !reflect.DeepEqual(actualFields[name], signedFields[name]) {
+			return errors.Errorf("%s does not match the signed airgap data", name)
+		}
+	}
+	return nil
+}
+
+func jsonFields(spec AirgapSpec) (map[string]interface{}, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal airgap spec")
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal airgap spec")
+	}
+	return fields, nil
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	"sort"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -52,11 +54,74 @@ type EmbeddedClusterArtifacts struct {
 	Metadata            string                  `json:"metadata,omitempty"`
 	Registry            EmbeddedClusterRegistry `json:"registry,omitempty"`
 	AdditionalArtifacts map[string]string       `json:"additionalArtifacts,omitempty"`
+	// Architectures maps an architecture, such as amd64 or arm64, to its images and binary.
+	// ImagesAmd64 and BinaryAmd64 are still read as the amd64 artifacts when amd64 is not
+	// in the map.
+	Architectures map[string]EmbeddedClusterArchArtifacts `json:"architectures,omitempty"`
+}
+
+// EmbeddedClusterArchArtifacts maps the embedded cluster artifacts built for a single
+// architecture to their path
+type EmbeddedClusterArchArtifacts struct {
+	Images string `json:"images,omitempty"`
+	Binary string `json:"binary,omitempty"`
+}
+
+// EmbeddedClusterLegacyArch is the architecture of ImagesAmd64 and BinaryAmd64
+const EmbeddedClusterLegacyArch = "amd64"
+
+// ArtifactsFor returns the images and binary paths for arch
+func (e *EmbeddedClusterArtifacts) ArtifactsFor(arch string) EmbeddedClusterArchArtifacts {
+	if e == nil {
+		return EmbeddedClusterArchArtifacts{}
+	}
+	artifacts := e.Architectures[arch]
+	if arch == EmbeddedClusterLegacyArch {
+		if artifacts.Images == "" {
+			artifacts.Images = e.ImagesAmd64
+		}
+		if artifacts.Binary == "" {
+			artifacts.Binary = e.BinaryAmd64
+		}
+	}
+	return artifacts
+}
+
+// ImagesFor returns the path of the images archive for arch, or an empty string
+func (e *EmbeddedClusterArtifacts) ImagesFor(arch string) string {
+	return e.ArtifactsFor(arch).Images
+}
+
+// BinaryFor returns the path of the embedded cluster binary for arch, or an empty string
+func (e *EmbeddedClusterArtifacts) BinaryFor(arch string) string {
+	return e.ArtifactsFor(arch).Binary
+}
+
+// Arches returns the sorted architectures that have artifacts, including amd64 when only
+// ImagesAmd64 or BinaryAmd64 is set
+func (e *EmbeddedClusterArtifacts) Arches() []string {
+	arches := []string{}
+	if e == nil {
+		return arches
+	}
+	candidates := []string{EmbeddedClusterLegacyArch}
+	for arch := range e.Architectures {
+		if arch != EmbeddedClusterLegacyArch {
+			candidates = append(candidates, arch)
+		}
+	}
+	for _, arch := range candidates {
+		if artifacts := e.ArtifactsFor(arch); artifacts.Images != "" || artifacts.Binary != "" {
+			arches = append(arches, arch)
+		}
+	}
+	sort.Strings(arches)
+	return arches
 }
 
 // Total returns the total amount of embedded cluster artifacts contained in
-// the airgap bundle. Sums up the amount of charts, images, binaries, metadata
-// and additional artifacts.
+// the airgap bundle. Sums up the amount of charts, images and binaries for
+// every architecture, metadata, additional artifacts and registry images.
 func (e *EmbeddedClusterArtifacts) Total() int {
 	total := 0
 	if e == nil {
@@ -65,11 +130,14 @@ func (e *EmbeddedClusterArtifacts) Total() int {
 	if e.Charts != "" {
 		total++
 	}
-	if e.ImagesAmd64 != "" {
-		total++
-	}
-	if e.BinaryAmd64 != "" {
-		total++
+	for _, arch := range e.Arches() {
+		artifacts := e.ArtifactsFor(arch)
+		if artifacts.Images != "" {
+			total++
+		}
+		if artifacts.Binary != "" {
+			total++
+		}
 	}
 	if e.Metadata != "" {
 		total++
@@ -0,0 +1,132 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+type KotsCompatibilityStatus string
+
+const (
+	KotsCompatibilitySupported    KotsCompatibilityStatus = "supported"
+	KotsCompatibilityBelowMinimum KotsCompatibilityStatus = "belowMinimum"
+	KotsCompatibilityAboveTarget  KotsCompatibilityStatus = "aboveTarget"
+)
+
+// KotsCompatibility is the result of checking a KOTS version against the MinKotsVersion and
+// TargetKotsVersion of an application
+// +kubebuilder:object:generate=false
+type KotsCompatibility struct {
+	Status KotsCompatibilityStatus
+	// Constraint is the MinKotsVersion or TargetKotsVersion that the version does not
+	// satisfy. It is empty when the version is supported.
+	Constraint string
+	Reason     string
+}
+
+func (c KotsCompatibility) IsSupported() bool {
+	return c.Status == KotsCompatibilitySupported
+}
+
+// InvalidKotsVersionError is returned when a KOTS version, or the MinKotsVersion or
+// TargetKotsVersion of an application, can't be parsed
+// +kubebuilder:object:generate=false
+type InvalidKotsVersionError struct {
+	// Field is "minKotsVersion" or "targetKotsVersion", or empty for the running version
+	Field   string
+	Version string
+	Err     error
+}
+
+func (e *InvalidKotsVersionError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("invalid kots version %q: %v", e.Version, e.Err)
+	}
+	return fmt.Sprintf("invalid %s %q: %v", e.Field, e.Version, e.Err)
+}
+
+func (e *InvalidKotsVersionError) Unwrap() error {
+	return e.Err
+}
+
+// CheckKotsCompatibility checks runningVersion, the version of KOTS the application is
+// installed with, against MinKotsVersion and TargetKotsVersion. Either may be a single
+// version, which is a minimum or a maximum, or a semver range such as ">= 1.60.0 < 2.0.0"
+// or "~1.60" that the version must satisfy. A partial target such as 1.70 covers every 1.70
+// patch. Versions may have a "v" prefix. Prereleases are ordered before their release, so
+// 1.60.0-beta.1 is below a MinKotsVersion of 1.60.0 but above one of 1.59.0.
+//
+// An *InvalidKotsVersionError is returned when runningVersion or either constraint can't be
+// parsed. Empty constraints are ignored.
+func (a *Application) CheckKotsCompatibility(runningVersion string) (KotsCompatibility, error) {
+	running, err := semver.NewVersion(strings.TrimSpace(runningVersion))
+	if err != nil {
+		return KotsCompatibility{}, &InvalidKotsVersionError{Version: runningVersion, Err: err}
+	}
+
+	minimum, err := parseKotsVersionConstraint(a.Spec.MinKotsVersion, ">=")
+	if err != nil {
+		return KotsCompatibility{}, &InvalidKotsVersionError{Field: "minKotsVersion", Version: a.Spec.MinKotsVersion, Err: err}
+	}
+	target, err := parseKotsVersionConstraint(a.Spec.TargetKotsVersion, "<=")
+	if err != nil {
+		return KotsCompatibility{}, &InvalidKotsVersionError{Field: "targetKotsVersion", Version: a.Spec.TargetKotsVersion, Err: err}
+	}
+
+	if minimum != nil && !minimum.Check(running) {
+		return KotsCompatibility{
+			Status:     KotsCompatibilityBelowMinimum,
+			Constraint: a.Spec.MinKotsVersion,
+			Reason:     kotsVersionReason(runningVersion, a.Spec.MinKotsVersion, "older than the minimum version", "the minimum version range"),
+		}, nil
+	}
+	if target != nil && !target.Check(running) {
+		return KotsCompatibility{
+			Status:     KotsCompatibilityAboveTarget,
+			Constraint: a.Spec.TargetKotsVersion,
+			Reason:     kotsVersionReason(runningVersion, a.Spec.TargetKotsVersion, "newer than the target version", "the target version range"),
+		}, nil
+	}
+
+	return KotsCompatibility{
+		Status: KotsCompatibilitySupported,
+		Reason: fmt.Sprintf("KOTS %s is supported by this application", runningVersion),
+	}, nil
+}
+
+// parseKotsVersionConstraint parses constraint, prefixing it with operator when it is a
+// single version with no operator of its own. A nil constraint is returned when it is empty.
+func parseKotsVersionConstraint(constraint string, operator string) (*semver.Constraints, error) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		return nil, nil
+	}
+	if isSingleKotsVersion(constraint) {
+		constraint = operator + " " + constraint
+	}
+
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, err
+	}
+	constraints.IncludePrerelease = true
+	return constraints, nil
+}
+
+func isSingleKotsVersion(constraint string) bool {
+	if strings.ContainsAny(constraint, " ,|") {
+		return false
+	}
+	version := strings.TrimPrefix(strings.TrimPrefix(constraint, "v"), "V")
+	return version != "" && version[0] >= '0' && version[0] <= '9'
+}
+
+func kotsVersionReason(runningVersion string, constraint string, versionReason string, rangeName string) string {
+	constraint = strings.TrimSpace(constraint)
+	if isSingleKotsVersion(constraint) {
+		return fmt.Sprintf("KOTS %s is %s %s of this application", runningVersion, versionReason, constraint)
+	}
+	return fmt.Sprintf("KOTS %s is outside %s %q of this application", runningVersion, rangeName, constraint)
+}
@@ -0,0 +1,159 @@
+package v1beta1
+
+type ConfigChangeType string
+
+const (
+	ConfigItemAdded          ConfigChangeType = "added"
+	ConfigItemRemoved        ConfigChangeType = "removed"
+	ConfigItemMoved          ConfigChangeType = "moved"
+	ConfigItemTypeChanged    ConfigChangeType = "typeChanged"
+	ConfigItemBecameRequired ConfigChangeType = "becameRequired"
+	ConfigItemDefaultChanged ConfigChangeType = "defaultChanged"
+)
+
+// ConfigChange is a single difference in a config item between two releases
+// +kubebuilder:object:generate=false
+type ConfigChange struct {
+	// Group is the item's group in the new spec, or in the old spec if the item was removed
+	Group string
+	Item  string
+	Type  ConfigChangeType
+	// Old and New are the old and new type, group or default, depending on Type
+	Old string
+	New string
+}
+
+// ConfigSpecDiff is the list of changes between two Config specs
+// +kubebuilder:object:generate=false
+type ConfigSpecDiff struct {
+	Changes []ConfigChange
+	// oldItems and newItems are kept to decide which changes break existing values
+	oldItems map[string]ConfigItem
+	newItems map[string]ConfigItem
+}
+
+// DiffConfigSpec compares the items of two Config specs. Items are matched by name, since
+// item names are unique across groups. Changes are ordered by the position of the item in
+// new, followed by removed items in the order they appear in old.
+func DiffConfigSpec(old, new ConfigSpec) *ConfigSpecDiff {
+	oldItems, oldGroups, oldOrder := configItemsByName(old)
+	newItems, newGroups, newOrder := configItemsByName(new)
+
+	diff := &ConfigSpecDiff{
+		Changes:  []ConfigChange{},
+		oldItems: oldItems,
+		newItems: newItems,
+	}
+
+	for _, name := range newOrder {
+		newItem := newItems[name]
+		group := newGroups[name]
+
+		oldItem, existed := oldItems[name]
+		if !existed {
+			diff.add(group, name, ConfigItemAdded, "", newItem.Type)
+			continue
+		}
+
+		if oldGroups[name] != group {
+			diff.add(group, name, ConfigItemMoved, oldGroups[name], group)
+		}
+		if oldItem.Type != newItem.Type {
+			diff.add(group, name, ConfigItemTypeChanged, oldItem.Type, newItem.Type)
+		}
+		if !oldItem.Required && newItem.Required {
+			diff.add(group, name, ConfigItemBecameRequired, "", "")
+		}
+		if oldItem.Default.String() != newItem.Default.String() {
+			diff.add(group, name, ConfigItemDefaultChanged, oldItem.Default.String(), newItem.Default.String())
+		}
+	}
+
+	for _, name := range oldOrder {
+		if _, exists := newItems[name]; !exists {
+			diff.add(oldGroups[name], name, ConfigItemRemoved, oldItems[name].Type, "")
+		}
+	}
+
+	return diff
+}
+
+func (d *ConfigSpecDiff) add(group string, item string, changeType ConfigChangeType, oldValue string, newValue string) {
+	d.Changes = append(d.Changes, ConfigChange{
+		Group: group,
+		Item:  item,
+		Type:  changeType,
+		Old:   oldValue,
+		New:   newValue,
+	})
+}
+
+// ByGroup returns the changes keyed by group name and then by item name
+func (d *ConfigSpecDiff) ByGroup() map[string]map[string][]ConfigChange {
+	result := map[string]map[string][]ConfigChange{}
+	for _, change := range d.Changes {
+		if result[change.Group] == nil {
+			result[change.Group] = map[string][]ConfigChange{}
+		}
+		result[change.Group][change.Item] = append(result[change.Group][change.Item], change)
+	}
+	return result
+}
+
+// BreakingChanges returns the changes that can break an installation with the given
+// values: removing an item or changing its type while values has a value for it, and
+// requiring an item that has no default when values has no value for it. values may be
+// nil, in which case only new required items without a default are breaking.
+func (d *ConfigSpecDiff) BreakingChanges(values *ConfigValues) []ConfigChange {
+	breaking := []ConfigChange{}
+	for _, change := range d.Changes {
+		switch change.Type {
+		case ConfigItemRemoved, ConfigItemTypeChanged:
+			if hasConfigValue(d.oldItems[change.Item], values) {
+				breaking = append(breaking, change)
+			}
+		case ConfigItemAdded, ConfigItemBecameRequired:
+			newItem := d.newItems[change.Item]
+			if newItem.Required && newItem.Default.IsEmpty() && newItem.Value.IsEmpty() && !hasConfigValue(newItem, values) {
+				breaking = append(breaking, change)
+			}
+		}
+	}
+	return breaking
+}
+
+// hasConfigValue reports whether values has a value for item, or for any of its repeated
+// values if it is repeatable
+func hasConfigValue(item ConfigItem, values *ConfigValues) bool {
+	if values == nil {
+		return false
+	}
+	for name, configValue := range values.Spec.Values {
+		if name != item.Name && configValue.RepeatableItem != item.Name {
+			continue
+		}
+		if configValue.Value != "" || configValue.ValuePlaintext != "" || configValue.Data != "" || configValue.DataPlaintext != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// configItemsByName returns the items in spec by name, the group of each item, and the
+// item names in the order they are declared. Only the first of duplicate names is kept.
+func configItemsByName(spec ConfigSpec) (map[string]ConfigItem, map[string]string, []string) {
+	items := map[string]ConfigItem{}
+	groups := map[string]string{}
+	order := []string{}
+	for _, group := range spec.Groups {
+		for _, item := range group.Items {
+			if _, ok := items[item.Name]; ok {
+				continue
+			}
+			items[item.Name] = item
+			groups[item.Name] = group.Name
+			order = append(order, item.Name)
+		}
+	}
+	return items, groups, order
+}
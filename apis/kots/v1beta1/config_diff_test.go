@@ -0,0 +1,107 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/replicatedhq/kotskinds/multitype"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiffConfigSpec(t *testing.T) {
+	old := ConfigSpec{
+		Groups: []ConfigGroup{
+			{
+				Name: "settings",
+				Items: []ConfigItem{
+					{Name: "hostname", Type: "text"},
+					{Name: "port", Type: "text", Default: multitype.FromString("80")},
+					{Name: "tls", Type: "text"},
+					{Name: "email", Type: "text"},
+					{Name: "legacy", Type: "text"},
+					{Name: "unused", Type: "text"},
+					{Name: "debug", Type: "bool"},
+				},
+			},
+		},
+	}
+	new := ConfigSpec{
+		Groups: []ConfigGroup{
+			{
+				Name: "settings",
+				Items: []ConfigItem{
+					{Name: "hostname", Type: "text", Required: true},
+					{Name: "port", Type: "text", Default: multitype.FromString("443")},
+					{Name: "tls", Type: "bool", Default: multitype.FromBool(true)},
+					{Name: "email", Type: "text", Required: true},
+					{Name: "license_key", Type: "password", Required: true},
+					{Name: "replicas", Type: "text", Required: true, Default: multitype.FromString("1")},
+				},
+			},
+			{
+				Name: "advanced",
+				Items: []ConfigItem{
+					{Name: "debug", Type: "bool"},
+				},
+			},
+		},
+	}
+
+	diff := DiffConfigSpec(old, new)
+	assert.Equal(t, []ConfigChange{
+		{Group: "settings", Item: "hostname", Type: ConfigItemBecameRequired},
+		{Group: "settings", Item: "port", Type: ConfigItemDefaultChanged, Old: "80", New: "443"},
+		{Group: "settings", Item: "tls", Type: ConfigItemTypeChanged, Old: "text", New: "bool"},
+		{Group: "settings", Item: "tls", Type: ConfigItemDefaultChanged, Old: "", New: "1"},
+		{Group: "settings", Item: "email", Type: ConfigItemBecameRequired},
+		{Group: "settings", Item: "license_key", Type: ConfigItemAdded, New: "password"},
+		{Group: "settings", Item: "replicas", Type: ConfigItemAdded, New: "text"},
+		{Group: "advanced", Item: "debug", Type: ConfigItemMoved, Old: "settings", New: "advanced"},
+		{Group: "settings", Item: "legacy", Type: ConfigItemRemoved, Old: "text"},
+		{Group: "settings", Item: "unused", Type: ConfigItemRemoved, Old: "text"},
+	}, diff.Changes)
+
+	byGroup := diff.ByGroup()
+	assert.Len(t, byGroup["settings"]["tls"], 2)
+	assert.Equal(t, ConfigItemMoved, byGroup["advanced"]["debug"][0].Type)
+
+	values := &ConfigValues{
+		Spec: ConfigValuesSpec{
+			Values: map[string]ConfigValue{
+				"hostname": {Value: "example.com"},
+				"tls":      {Value: "yes"},
+				"legacy":   {Value: "x"},
+				"unused":   {Default: "only a default"},
+			},
+		},
+	}
+
+	assert.Equal(t, []ConfigChange{
+		{Group: "settings", Item: "tls", Type: ConfigItemTypeChanged, Old: "text", New: "bool"},
+		{Group: "settings", Item: "email", Type: ConfigItemBecameRequired},
+		{Group: "settings", Item: "license_key", Type: ConfigItemAdded, New: "password"},
+		{Group: "settings", Item: "legacy", Type: ConfigItemRemoved, Old: "text"},
+	}, diff.BreakingChanges(values))
+
+	assert.Equal(t, []ConfigChange{
+		{Group: "settings", Item: "hostname", Type: ConfigItemBecameRequired},
+		{Group: "settings", Item: "email", Type: ConfigItemBecameRequired},
+		{Group: "settings", Item: "license_key", Type: ConfigItemAdded, New: "password"},
+	}, diff.BreakingChanges(nil))
+}
+
+func Test_DiffConfigSpec_RepeatableValues(t *testing.T) {
+	old := ConfigSpec{Groups: []ConfigGroup{{Name: "g", Items: []ConfigItem{{Name: "host", Type: "text", Repeatable: true}}}}}
+	new := ConfigSpec{Groups: []ConfigGroup{{Name: "g"}}}
+
+	values := &ConfigValues{
+		Spec: ConfigValuesSpec{
+			Values: map[string]ConfigValue{
+				"host-1": {Value: "a", RepeatableItem: "host"},
+			},
+		},
+	}
+
+	diff := DiffConfigSpec(old, new)
+	assert.Len(t, diff.BreakingChanges(values), 1)
+	assert.Empty(t, diff.BreakingChanges(nil))
+}
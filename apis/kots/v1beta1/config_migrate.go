@@ -0,0 +1,232 @@
+package v1beta1
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type ConfigMigrationAction string
+
+const (
+	// ConfigValueRenamed is a value moved to a new name using the rename map
+	ConfigValueRenamed ConfigMigrationAction = "renamed"
+	// ConfigValueConverted is a value changed to fit the type of its item in the new config
+	ConfigValueConverted ConfigMigrationAction = "converted"
+	// ConfigValueDropped is a value for an item that is not in the new config
+	ConfigValueDropped ConfigMigrationAction = "dropped"
+	// ConfigValueFailed is a value that could not be migrated, and was left out of the result
+	ConfigValueFailed ConfigMigrationAction = "failed"
+)
+
+// ConfigMigrationEntry describes what happened to a single value during a migration
+// +kubebuilder:object:generate=false
+type ConfigMigrationEntry struct {
+	// Name is the key of the value in the original ConfigValues
+	Name string
+	// NewName is the key of the value in the migrated ConfigValues, empty if it was left out
+	NewName string
+	Action  ConfigMigrationAction
+	Message string
+}
+
+// ConfigMigrationReport lists the values that were changed or left out by a migration.
+// Values that were carried over unchanged are not listed.
+// +kubebuilder:object:generate=false
+type ConfigMigrationReport struct {
+	Entries []ConfigMigrationEntry
+}
+
+// Failed returns the entries for values that could not be migrated
+func (r *ConfigMigrationReport) Failed() []ConfigMigrationEntry {
+	failed := []ConfigMigrationEntry{}
+	for _, entry := range r.Entries {
+		if entry.Action == ConfigValueFailed {
+			failed = append(failed, entry)
+		}
+	}
+	return failed
+}
+
+// MigrateConfigValues returns a copy of values that fits newConfig, and a report of what was
+// changed. values is not modified.
+//
+// Values are renamed using renames, which maps old item names to new ones, and may be nil.
+// For repeated values of a repeatable item, the value keeps its name and RepeatableItem is
+// renamed instead. Values for items that are not in newConfig are dropped.
+//
+// When an item's type is different in oldConfig and newConfig, or the value does not fit the
+// new type, the value is converted where possible: bools are normalized to "1" and "0",
+// values moving between password and other types are moved between Value and
+// ValuePlaintext, and select_one values must be one of the item's options. Encrypted
+// password values can't be converted to other types without being decrypted first, see
+// ConfigValues.DecryptSecrets.
+func MigrateConfigValues(oldConfig, newConfig *Config, values *ConfigValues, renames map[string]string) (*ConfigValues, *ConfigMigrationReport) {
+	report := &ConfigMigrationReport{Entries: []ConfigMigrationEntry{}}
+
+	migrated := &ConfigValues{}
+	if values != nil {
+		migrated = values.DeepCopy()
+	}
+	migrated.Spec.Values = map[string]ConfigValue{}
+	if values == nil {
+		return migrated, report
+	}
+
+	oldItems := map[string]ConfigItem{}
+	if oldConfig != nil {
+		oldItems, _, _ = configItemsByName(oldConfig.Spec)
+	}
+	newItems := map[string]ConfigItem{}
+	if newConfig != nil {
+		newItems, _, _ = configItemsByName(newConfig.Spec)
+	}
+
+	names := make([]string, 0, len(values.Spec.Values))
+	for name := range values.Spec.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// values that are not renamed are placed first, so that a renamed value never replaces
+	// a value that was already there under the new name
+	sort.SliceStable(names, func(i, j int) bool {
+		return !isRenamedValue(names[i], values.Spec.Values[names[i]], renames) && isRenamedValue(names[j], values.Spec.Values[names[j]], renames)
+	})
+
+	for _, name := range names {
+		configValue := values.Spec.Values[name]
+
+		oldItemName := name
+		if configValue.RepeatableItem != "" {
+			oldItemName = configValue.RepeatableItem
+		}
+		newItemName := oldItemName
+		if renamed, ok := renames[oldItemName]; ok {
+			newItemName = renamed
+		}
+		newName := name
+		if configValue.RepeatableItem != "" {
+			configValue.RepeatableItem = newItemName
+		} else {
+			newName = newItemName
+		}
+
+		newItem, ok := newItems[newItemName]
+		if !ok {
+			report.add(name, "", ConfigValueDropped, fmt.Sprintf("item %s is not in the new config", newItemName))
+			continue
+		}
+
+		if _, exists := migrated.Spec.Values[newName]; exists {
+			report.add(name, "", ConfigValueFailed, fmt.Sprintf("a value for %s already exists", newName))
+			continue
+		}
+
+		oldKind := newItem.Kind()
+		if oldItem, ok := oldItems[oldItemName]; ok {
+			oldKind = oldItem.Kind()
+		}
+
+		converted, message, err := convertConfigValue(configValue, oldKind, newItem)
+		if err != nil {
+			report.add(name, "", ConfigValueFailed, err.Error())
+			continue
+		}
+
+		if newName != name || newItemName != oldItemName {
+			report.add(name, newName, ConfigValueRenamed, fmt.Sprintf("item %s was renamed to %s", oldItemName, newItemName))
+		}
+		if message != "" {
+			report.add(name, newName, ConfigValueConverted, message)
+		}
+
+		migrated.Spec.Values[newName] = converted
+	}
+
+	return migrated, report
+}
+
+func (r *ConfigMigrationReport) add(name string, newName string, action ConfigMigrationAction, message string) {
+	r.Entries = append(r.Entries, ConfigMigrationEntry{
+		Name:    name,
+		NewName: newName,
+		Action:  action,
+		Message: message,
+	})
+}
+
+func isRenamedValue(name string, configValue ConfigValue, renames map[string]string) bool {
+	if configValue.RepeatableItem != "" {
+		return false
+	}
+	_, ok := renames[name]
+	return ok
+}
+
+// convertConfigValue converts a value from an item of oldKind to fit newItem. It returns the
+// converted value, and a message describing the conversion if the value was changed.
+func convertConfigValue(configValue ConfigValue, oldKind ConfigItemKind, newItem ConfigItem) (ConfigValue, string, error) {
+	newKind := newItem.Kind()
+
+	if !newKind.HasValue() {
+		return configValue, "", errors.Errorf("%s items have no value", newKind)
+	}
+
+	messages := []string{}
+
+	if oldKind != newKind {
+		switch {
+		case oldKind == ConfigItemKindFile || newKind == ConfigItemKindFile:
+			return configValue, "", errors.Errorf("can't convert a %s value to %s", oldKind, newKind)
+
+		case oldKind == ConfigItemKindPassword:
+			if configValue.ValuePlaintext == "" && configValue.Value != "" {
+				return configValue, "", errors.Errorf("can't convert an encrypted %s value to %s", oldKind, newKind)
+			}
+			if configValue.ValuePlaintext != "" {
+				configValue.Value = configValue.ValuePlaintext
+				configValue.ValuePlaintext = ""
+				messages = append(messages, "moved from valuePlaintext to value")
+			}
+
+		case newKind == ConfigItemKindPassword:
+			if configValue.Value != "" {
+				configValue.ValuePlaintext = configValue.Value
+				configValue.Value = ""
+				messages = append(messages, "moved from value to valuePlaintext")
+			}
+		}
+	}
+
+	switch newKind {
+	case ConfigItemKindBool:
+		for _, field := range []*string{&configValue.Value, &configValue.Default} {
+			if *field == "" {
+				continue
+			}
+			parsed, err := strconv.ParseBool(*field)
+			if err != nil {
+				return configValue, "", errors.Errorf("%q is not a valid bool", *field)
+			}
+			normalized := "0"
+			if parsed {
+				normalized = "1"
+			}
+			if normalized != *field {
+				messages = append(messages, fmt.Sprintf("%q normalized to %q", *field, normalized))
+				*field = normalized
+			}
+		}
+
+	case ConfigItemKindSelectOne, ConfigItemKindRadio, ConfigItemKindDropdown:
+		if configValue.Value != "" && !newItem.HasOption(configValue.Value) {
+			return configValue, "", errors.Errorf("%q is not one of the options of %s", configValue.Value, newItem.Name)
+		}
+	}
+
+	return configValue, strings.Join(messages, ", "), nil
+}
@@ -0,0 +1,112 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MigrateConfigValues(t *testing.T) {
+	oldConfig := &Config{
+		Spec: ConfigSpec{
+			Groups: []ConfigGroup{
+				{
+					Name: "settings",
+					Items: []ConfigItem{
+						{Name: "hostname", Type: "text"},
+						{Name: "enable_tls", Type: "text"},
+						{Name: "debug", Type: "bool"},
+						{Name: "legacy", Type: "text"},
+						{Name: "secret", Type: "text"},
+						{Name: "token", Type: "password"},
+						{Name: "encrypted", Type: "password"},
+						{Name: "mode", Type: "text"},
+						{Name: "host", Type: "text", Repeatable: true},
+						{Name: "old_port", Type: "text"},
+						{Name: "port", Type: "text"},
+					},
+				},
+			},
+		},
+	}
+	newConfig := &Config{
+		Spec: ConfigSpec{
+			Groups: []ConfigGroup{
+				{
+					Name: "settings",
+					Items: []ConfigItem{
+						{Name: "hostname", Type: "text"},
+						{Name: "tls", Type: "bool"},
+						{Name: "debug", Type: "bool"},
+						{Name: "secret", Type: "password"},
+						{Name: "token", Type: "text"},
+						{Name: "encrypted", Type: "text"},
+						{Name: "mode", Type: "select_one", Items: []ConfigChildItem{{Name: "a"}}},
+						{Name: "hosts", Type: "text", Repeatable: true},
+						{Name: "port", Type: "text"},
+					},
+				},
+			},
+		},
+	}
+
+	values := &ConfigValues{
+		Spec: ConfigValuesSpec{
+			Values: map[string]ConfigValue{
+				"hostname":   {Value: "example.com"},
+				"enable_tls": {Value: "true", Default: "false"},
+				"debug":      {Value: "1"},
+				"legacy":     {Value: "x"},
+				"secret":     {Value: "hunter2"},
+				"token":      {ValuePlaintext: "abc"},
+				"encrypted":  {Value: "ZW5jcnlwdGVk"},
+				"mode":       {Value: "b"},
+				"host-1":     {Value: "a.example.com", RepeatableItem: "host"},
+				"old_port":   {Value: "8080"},
+				"port":       {Value: "443"},
+			},
+		},
+	}
+
+	renames := map[string]string{
+		"enable_tls": "tls",
+		"host":       "hosts",
+		"old_port":   "port",
+	}
+
+	migrated, report := MigrateConfigValues(oldConfig, newConfig, values, renames)
+
+	assert.Equal(t, map[string]ConfigValue{
+		"hostname": {Value: "example.com"},
+		"tls":      {Value: "1", Default: "0"},
+		"debug":    {Value: "1"},
+		"secret":   {ValuePlaintext: "hunter2"},
+		"token":    {Value: "abc"},
+		"host-1":   {Value: "a.example.com", RepeatableItem: "hosts"},
+		"port":     {Value: "443"},
+	}, migrated.Spec.Values)
+
+	assert.Equal(t, []ConfigMigrationEntry{
+		{Name: "encrypted", Action: ConfigValueFailed, Message: "can't convert an encrypted password value to text"},
+		{Name: "host-1", NewName: "host-1", Action: ConfigValueRenamed, Message: "item host was renamed to hosts"},
+		{Name: "legacy", Action: ConfigValueDropped, Message: "item legacy is not in the new config"},
+		{Name: "mode", Action: ConfigValueFailed, Message: `"b" is not one of the options of mode`},
+		{Name: "secret", NewName: "secret", Action: ConfigValueConverted, Message: "moved from value to valuePlaintext"},
+		{Name: "token", NewName: "token", Action: ConfigValueConverted, Message: "moved from valuePlaintext to value"},
+		{Name: "enable_tls", NewName: "tls", Action: ConfigValueRenamed, Message: "item enable_tls was renamed to tls"},
+		{Name: "enable_tls", NewName: "tls", Action: ConfigValueConverted, Message: `"true" normalized to "1", "false" normalized to "0"`},
+		{Name: "old_port", Action: ConfigValueFailed, Message: "a value for port already exists"},
+	}, report.Entries)
+
+	assert.Len(t, report.Failed(), 3)
+
+	// the input is not modified
+	assert.Equal(t, "host", values.Spec.Values["host-1"].RepeatableItem)
+	assert.Len(t, values.Spec.Values, 11)
+}
+
+func Test_MigrateConfigValues_Nil(t *testing.T) {
+	migrated, report := MigrateConfigValues(nil, &Config{}, nil, nil)
+	assert.Empty(t, migrated.Spec.Values)
+	assert.Empty(t, report.Entries)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/replicatedhq/kotskinds/multitype"
@@ -31,7 +32,8 @@ type ConfigChildItem struct {
 }
 
 type ConfigItem struct {
-	Name          string                 `json:"name"`
+	Name string `json:"name"`
+	// +kubebuilder:validation:Enum=text;password;bool;select_one;radio;dropdown;file;heading;label;textarea
 	Type          string                 `json:"type"`
 	Title         string                 `json:"title,omitempty"`
 	HelpText      string                 `json:"help_text,omitempty"`
@@ -63,6 +65,58 @@ type ConfigItem struct {
 	// DataCmd     *ConfigItemCmd         `json:"data_cmd,omitempty"`
 }
 
+// ConfigItemKind is the type of a ConfigItem, which determines how its Value, Default,
+// Items and Filename are interpreted
+type ConfigItemKind string
+
+const (
+	ConfigItemKindText      ConfigItemKind = "text"
+	ConfigItemKindPassword  ConfigItemKind = "password"
+	ConfigItemKindBool      ConfigItemKind = "bool"
+	ConfigItemKindSelectOne ConfigItemKind = "select_one"
+	ConfigItemKindRadio     ConfigItemKind = "radio"
+	ConfigItemKindDropdown  ConfigItemKind = "dropdown"
+	ConfigItemKindFile      ConfigItemKind = "file"
+	ConfigItemKindHeading   ConfigItemKind = "heading"
+	ConfigItemKindLabel     ConfigItemKind = "label"
+	ConfigItemKindTextarea  ConfigItemKind = "textarea"
+)
+
+// ConfigItemKinds are all the supported config item kinds
+var ConfigItemKinds = []ConfigItemKind{
+	ConfigItemKindText,
+	ConfigItemKindPassword,
+	ConfigItemKindBool,
+	ConfigItemKindSelectOne,
+	ConfigItemKindRadio,
+	ConfigItemKindDropdown,
+	ConfigItemKindFile,
+	ConfigItemKindHeading,
+	ConfigItemKindLabel,
+	ConfigItemKindTextarea,
+}
+
+// IsValid returns true if the kind is one of ConfigItemKinds
+func (k ConfigItemKind) IsValid() bool {
+	for _, kind := range ConfigItemKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOptions returns true for the kinds whose value is the name of one of the item's Items:
+// select_one, radio and dropdown
+func (k ConfigItemKind) HasOptions() bool {
+	return k == ConfigItemKindSelectOne || k == ConfigItemKindRadio || k == ConfigItemKindDropdown
+}
+
+// HasValue returns false for kinds that only display text and never hold a value
+func (k ConfigItemKind) HasValue() bool {
+	return k != ConfigItemKindHeading && k != ConfigItemKindLabel
+}
+
 type ConfigItemValidation struct {
 	Regex *RegexValidator `json:"regex,omitempty"`
 }
@@ -126,3 +180,108 @@ type ConfigList struct {
 func init() {
 	SchemeBuilder.Register(&Config{}, &ConfigList{})
 }
+
+// Kind returns the item's Type as a ConfigItemKind
+func (i ConfigItem) Kind() ConfigItemKind {
+	return ConfigItemKind(i.Type)
+}
+
+// effectiveValue returns Value when it is set, otherwise Default
+func (i ConfigItem) effectiveValue() multitype.BoolOrString {
+	if !i.Value.IsEmpty() {
+		return i.Value
+	}
+	return i.Default
+}
+
+// StringValue returns the item's Value, or its Default when Value is empty, as a string.
+// Bools are returned as "1" or "0".
+func (i ConfigItem) StringValue() string {
+	value := i.effectiveValue()
+	return value.String()
+}
+
+// BoolValue returns the item's Value, or its Default when Value is empty, as a bool. It is
+// an error to call BoolValue on an item that is not a bool, or whose value is not a valid
+// bool. Items with no value are false.
+func (i ConfigItem) BoolValue() (bool, error) {
+	if i.Kind() != ConfigItemKindBool {
+		return false, errors.Errorf("item %s is %q, not %q", i.Name, i.Type, ConfigItemKindBool)
+	}
+
+	value := i.effectiveValue()
+	if value.IsEmpty() {
+		return false, nil
+	}
+	parsed, err := value.Bool()
+	if err != nil {
+		return false, errors.Wrapf(err, "item %s", i.Name)
+	}
+	return parsed, nil
+}
+
+// SelectedItem returns the child item selected by the item's Value, or its Default when
+// Value is empty. It returns nil when nothing is selected. It is an error to call
+// SelectedItem on an item that is not a select_one, radio or dropdown, or whose value is
+// not one of its Items.
+func (i ConfigItem) SelectedItem() (*ConfigChildItem, error) {
+	if !i.Kind().HasOptions() {
+		return nil, errors.Errorf("item %s is %q, which has no options", i.Name, i.Type)
+	}
+
+	value := i.effectiveValue()
+	if value.IsEmpty() {
+		return nil, nil
+	}
+	name := value.String()
+	for idx := range i.Items {
+		if i.Items[idx].Name == name {
+			return &i.Items[idx], nil
+		}
+	}
+	return nil, errors.Errorf("item %s has no option %q", i.Name, name)
+}
+
+// HasOption returns true if name is one of the item's Items
+func (i ConfigItem) HasOption(name string) bool {
+	for _, child := range i.Items {
+		if child.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateKind checks that the item's fields make sense for its kind: the kind is known,
+// Items are only set on select_one, radio and dropdown items, Filename is only set on file
+// items, headings and labels have no value, and bool and option values can be coerced.
+func (i ConfigItem) ValidateKind() error {
+	kind := i.Kind()
+	if !kind.IsValid() {
+		return errors.Errorf("item %s has unknown type %q", i.Name, i.Type)
+	}
+
+	if len(i.Items) > 0 && !kind.HasOptions() {
+		return errors.Errorf("item %s is %q, only %q, %q and %q items have items", i.Name, i.Type, ConfigItemKindSelectOne, ConfigItemKindRadio, ConfigItemKindDropdown)
+	}
+	if i.Filename != "" && kind != ConfigItemKindFile {
+		return errors.Errorf("item %s is %q, only %q items have a filename", i.Name, i.Type, ConfigItemKindFile)
+	}
+
+	switch kind {
+	case ConfigItemKindHeading, ConfigItemKindLabel:
+		if !i.Value.IsEmpty() || !i.Default.IsEmpty() {
+			return errors.Errorf("item %s is %q and cannot have a value or default", i.Name, i.Type)
+		}
+	case ConfigItemKindBool:
+		if _, err := i.BoolValue(); err != nil {
+			return err
+		}
+	case ConfigItemKindSelectOne, ConfigItemKindRadio, ConfigItemKindDropdown:
+		if _, err := i.SelectedItem(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/replicatedhq/kotskinds/multitype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConfigItemKind(t *testing.T) {
+	assert.Equal(t, ConfigItemKindSelectOne, ConfigItem{Type: "select_one"}.Kind())
+	assert.True(t, ConfigItemKindTextarea.IsValid())
+	assert.True(t, ConfigItemKindRadio.IsValid())
+	assert.True(t, ConfigItemKindDropdown.IsValid())
+	assert.False(t, ConfigItemKind("checkbox").IsValid())
+	assert.False(t, ConfigItemKind("").IsValid())
+	assert.True(t, ConfigItemKindPassword.HasValue())
+	assert.False(t, ConfigItemKindHeading.HasValue())
+	assert.False(t, ConfigItemKindLabel.HasValue())
+	assert.True(t, ConfigItemKindRadio.HasOptions())
+	assert.False(t, ConfigItemKindText.HasOptions())
+}
+
+func Test_ConfigItemBoolValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     ConfigItem
+		expected bool
+		wantErr  bool
+	}{
+		{
+			name:     "bool value",
+			item:     ConfigItem{Type: "bool", Value: multitype.FromBool(true)},
+			expected: true,
+		},
+		{
+			name:     "string value",
+			item:     ConfigItem{Type: "bool", Value: multitype.FromString("0"), Default: multitype.FromString("1")},
+			expected: false,
+		},
+		{
+			name:     "default",
+			item:     ConfigItem{Type: "bool", Default: multitype.FromString("1")},
+			expected: true,
+		},
+		{
+			name:     "no value",
+			item:     ConfigItem{Type: "bool"},
+			expected: false,
+		},
+		{
+			name:    "malformed",
+			item:    ConfigItem{Type: "bool", Value: multitype.FromString("maybe")},
+			wantErr: true,
+		},
+		{
+			name:    "not a bool",
+			item:    ConfigItem{Type: "text", Value: multitype.FromString("1")},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := test.item.BoolValue()
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func Test_ConfigItemSelectedItem(t *testing.T) {
+	item := ConfigItem{
+		Name:    "mode",
+		Type:    "select_one",
+		Default: multitype.FromString("embedded"),
+		Items: []ConfigChildItem{
+			{Name: "embedded", Title: "Embedded"},
+			{Name: "external", Title: "External"},
+		},
+	}
+
+	selected, err := item.SelectedItem()
+	require.NoError(t, err)
+	assert.Equal(t, "Embedded", selected.Title)
+
+	item.Value = multitype.FromString("external")
+	selected, err = item.SelectedItem()
+	require.NoError(t, err)
+	assert.Equal(t, "External", selected.Title)
+	assert.Equal(t, "external", item.StringValue())
+
+	item.Value = multitype.FromString("cloud")
+	_, err = item.SelectedItem()
+	require.Error(t, err)
+
+	item.Value = multitype.FromString("")
+	item.Default = multitype.FromString("")
+	selected, err = item.SelectedItem()
+	require.NoError(t, err)
+	assert.Nil(t, selected)
+
+	_, err = ConfigItem{Type: "text"}.SelectedItem()
+	require.Error(t, err)
+}
+
+func Test_ConfigItemValidateKind(t *testing.T) {
+	tests := []struct {
+		name    string
+		item    ConfigItem
+		wantErr bool
+	}{
+		{name: "text", item: ConfigItem{Type: "text", Default: multitype.FromString("a")}},
+		{name: "file", item: ConfigItem{Type: "file", Filename: "a.txt"}},
+		{name: "select one", item: ConfigItem{Type: "select_one", Default: multitype.FromString("a"), Items: []ConfigChildItem{{Name: "a"}}}},
+		{name: "radio", item: ConfigItem{Type: "radio", Default: multitype.FromString("a"), Items: []ConfigChildItem{{Name: "a"}}}},
+		{name: "dropdown", item: ConfigItem{Type: "dropdown", Items: []ConfigChildItem{{Name: "a"}}}},
+		{name: "unknown type", item: ConfigItem{Type: "checkbox"}, wantErr: true},
+		{name: "dropdown default not an option", item: ConfigItem{Type: "dropdown", Default: multitype.FromString("b"), Items: []ConfigChildItem{{Name: "a"}}}, wantErr: true},
+		{name: "items on text", item: ConfigItem{Type: "text", Items: []ConfigChildItem{{Name: "a"}}}, wantErr: true},
+		{name: "filename on textarea", item: ConfigItem{Type: "textarea", Filename: "a.txt"}, wantErr: true},
+		{name: "heading with value", item: ConfigItem{Type: "heading", Value: multitype.FromString("a")}, wantErr: true},
+		{name: "malformed bool default", item: ConfigItem{Type: "bool", Default: multitype.FromString("yes please")}, wantErr: true},
+		{name: "select one default not an option", item: ConfigItem{Type: "select_one", Default: multitype.FromString("b"), Items: []ConfigChildItem{{Name: "a"}}}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.item.ValidateKind()
+			if test.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package v1beta1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kotskinds/pkg/repltemplate"
+)
+
+type ConfigValidationReason string
+
+const (
+	ConfigValidationRequired      ConfigValidationReason = "required"
+	ConfigValidationRegex         ConfigValidationReason = "regex"
+	ConfigValidationMinimumCount  ConfigValidationReason = "minimumCount"
+	ConfigValidationInvalidOption ConfigValidationReason = "invalidOption"
+	ConfigValidationInvalidBool   ConfigValidationReason = "invalidBool"
+)
+
+// ConfigItemValidationError describes a single config item whose value does not satisfy
+// the rules declared on it
+// +kubebuilder:object:generate=false
+type ConfigItemValidationError struct {
+	Group string
+	Item  string
+	// ValueName is the name of the value that failed validation. It differs from Item
+	// only for repeatable items, where each repeated value is validated separately.
+	ValueName string
+	Reason    ConfigValidationReason
+	Message   string
+}
+
+func (e ConfigItemValidationError) Error() string {
+	if e.ValueName != "" && e.ValueName != e.Item {
+		return fmt.Sprintf("%s/%s (%s): %s", e.Group, e.Item, e.ValueName, e.Message)
+	}
+	return fmt.Sprintf("%s/%s: %s", e.Group, e.Item, e.Message)
+}
+
+// ValidateConfigValues checks values against the Required, Validation, MinimumCount and
+// Type of each item in config. Items that are hidden, or whose own or group's `when` is
+// false, are skipped. `when` is not rendered, so only literal false values hide an item.
+//
+// Values are resolved the way KOTS resolves them: the value from values, then the item's
+// value, then the default from values, then the item's default. Values that contain repl
+// templates are only checked for being set, as they are not known until rendered.
+//
+// An error is returned only when the config itself can't be used, such as an invalid regex
+// pattern.
+func ValidateConfigValues(config *Config, values *ConfigValues) ([]ConfigItemValidationError, error) {
+	if config == nil {
+		return nil, errors.New("config is required")
+	}
+
+	configValues := map[string]ConfigValue{}
+	if values != nil && values.Spec.Values != nil {
+		configValues = values.Spec.Values
+	}
+
+	validationErrors := []ConfigItemValidationError{}
+	for _, group := range config.Spec.Groups {
+		if isWhenFalse(string(group.When)) {
+			continue
+		}
+
+		for _, item := range group.Items {
+			if item.Hidden || isWhenFalse(string(item.When)) {
+				continue
+			}
+
+			itemErrors, err := validateConfigItem(item, group.Name, configValues)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to validate item %s", item.Name)
+			}
+			for _, itemError := range itemErrors {
+				itemError.Group = group.Name
+				itemError.Item = item.Name
+				validationErrors = append(validationErrors, itemError)
+			}
+		}
+	}
+
+	return validationErrors, nil
+}
+
+func validateConfigItem(item ConfigItem, groupName string, configValues map[string]ConfigValue) ([]ConfigItemValidationError, error) {
+	if !item.Kind().HasValue() {
+		return nil, nil
+	}
+
+	var regex *regexp.Regexp
+	if item.Validation != nil && item.Validation.Regex != nil {
+		var err error
+		regex, err = regexp.Compile(item.Validation.Regex.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid regex pattern %q", item.Validation.Regex.Pattern)
+		}
+	}
+
+	validationErrors := []ConfigItemValidationError{}
+
+	valueNames := []string{item.Name}
+	if item.Repeatable {
+		valueNames = repeatableValueNames(item, groupName, configValues)
+		if len(valueNames) < item.MinimumCount {
+			validationErrors = append(validationErrors, ConfigItemValidationError{
+				ValueName: item.Name,
+				Reason:    ConfigValidationMinimumCount,
+				Message:   fmt.Sprintf("at least %d values are required, found %d", item.MinimumCount, len(valueNames)),
+			})
+		}
+	}
+
+	for _, valueName := range valueNames {
+		value, isSet, isPlaintext := resolveConfigItemValue(item, valueName, groupName, configValues)
+
+		if !isSet {
+			if item.Required {
+				validationErrors = append(validationErrors, ConfigItemValidationError{
+					ValueName: valueName,
+					Reason:    ConfigValidationRequired,
+					Message:   "a value is required",
+				})
+			}
+			continue
+		}
+		if !isPlaintext {
+			// an encrypted value can't be checked without its plaintext
+			continue
+		}
+		if repltemplate.IsTemplate(value) {
+			continue
+		}
+
+		switch item.Kind() {
+		case ConfigItemKindBool:
+			if _, err := strconv.ParseBool(value); err != nil {
+				validationErrors = append(validationErrors, ConfigItemValidationError{
+					ValueName: valueName,
+					Reason:    ConfigValidationInvalidBool,
+					Message:   fmt.Sprintf("%q is not a valid bool", value),
+				})
+			}
+		case ConfigItemKindSelectOne, ConfigItemKindRadio, ConfigItemKindDropdown:
+			if !item.HasOption(value) {
+				validationErrors = append(validationErrors, ConfigItemValidationError{
+					ValueName: valueName,
+					Reason:    ConfigValidationInvalidOption,
+					Message:   fmt.Sprintf("%q is not one of the available options", value),
+				})
+			}
+		}
+
+		if regex != nil && !regex.MatchString(value) {
+			message := item.Validation.Regex.Message
+			if message == "" {
+				message = fmt.Sprintf("value does not match pattern %q", item.Validation.Regex.Pattern)
+			}
+			validationErrors = append(validationErrors, ConfigItemValidationError{
+				ValueName: valueName,
+				Reason:    ConfigValidationRegex,
+				Message:   message,
+			})
+		}
+	}
+
+	return validationErrors, nil
+}
+
+// resolveConfigItemValue returns the value of an item, whether it is set, and whether the
+// value is its plaintext. For repeatable items, valueName is the name of one repeated value.
+// Encrypted values that have no plaintext are reported as set, but their value is not
+// returned.
+func resolveConfigItemValue(item ConfigItem, valueName string, groupName string, configValues map[string]ConfigValue) (string, bool, bool) {
+	configValue, hasConfigValue := configValues[valueName]
+	if hasConfigValue {
+		if configValue.ValuePlaintext != "" {
+			return configValue.ValuePlaintext, true, true
+		}
+		if configValue.Value != "" {
+			if item.Kind() == ConfigItemKindPassword {
+				return "", true, false
+			}
+			return configValue.Value, true, true
+		}
+	}
+
+	if item.Repeatable {
+		if groupValues, ok := item.ValuesByGroup[groupName]; ok && groupValues[valueName] != "" {
+			return groupValues[valueName], true, true
+		}
+	}
+
+	if !item.Value.IsEmpty() {
+		return item.Value.String(), true, true
+	}
+	if hasConfigValue && configValue.Default != "" {
+		return configValue.Default, true, true
+	}
+	if !item.Default.IsEmpty() {
+		return item.Default.String(), true, true
+	}
+
+	return "", false, false
+}
+
+// repeatableValueNames returns the names of the values of a repeatable item, from both
+// the values that point back to it and the item's own ValuesByGroup
+func repeatableValueNames(item ConfigItem, groupName string, configValues map[string]ConfigValue) []string {
+	names := map[string]bool{}
+	for name, configValue := range configValues {
+		if configValue.RepeatableItem == item.Name {
+			names[name] = true
+		}
+	}
+	for name := range item.ValuesByGroup[groupName] {
+		names[name] = true
+	}
+
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// isWhenFalse reports whether a `when` value is a literal false. Empty and templated values
+// are not false.
+func isWhenFalse(when string) bool {
+	if when == "" {
+		return false
+	}
+	parsed, err := strconv.ParseBool(when)
+	return err == nil && !parsed
+}
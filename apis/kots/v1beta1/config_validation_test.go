@@ -0,0 +1,233 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/replicatedhq/kotskinds/multitype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ValidateConfigValues(t *testing.T) {
+	config := &Config{
+		Spec: ConfigSpec{
+			Groups: []ConfigGroup{
+				{
+					Name: "database",
+					Items: []ConfigItem{
+						{Name: "heading", Type: "heading", Required: true},
+						{Name: "hostname", Type: "text", Required: true},
+						{
+							Name:     "port",
+							Type:     "text",
+							Required: true,
+							Default:  multitype.FromString("5432"),
+							Validation: &ConfigItemValidation{
+								Regex: &RegexValidator{Pattern: `^[0-9]+$`, Message: "port must be a number"},
+							},
+						},
+						{
+							Name: "username",
+							Type: "text",
+							Validation: &ConfigItemValidation{
+								Regex: &RegexValidator{Pattern: `^[a-z]+$`},
+							},
+						},
+						{Name: "password", Type: "password", Required: true},
+						{Name: "tls", Type: "bool"},
+						{
+							Name: "mode",
+							Type: "select_one",
+							Items: []ConfigChildItem{
+								{Name: "embedded"},
+								{Name: "external"},
+							},
+						},
+						{Name: "hidden", Type: "text", Required: true, Hidden: true},
+						{Name: "disabled", Type: "text", Required: true, When: "false"},
+						{Name: "templated", Type: "text", Required: true, When: `repl{{ ConfigOptionEquals "mode" "external" }}`},
+					},
+				},
+				{
+					Name: "hosts",
+					Items: []ConfigItem{
+						{
+							Name:         "host",
+							Type:         "text",
+							Repeatable:   true,
+							MinimumCount: 3,
+							Validation: &ConfigItemValidation{
+								Regex: &RegexValidator{Pattern: `^[a-z.]+$`, Message: "invalid host"},
+							},
+							ValuesByGroup: ValuesByGroup{
+								"hosts": GroupValues{"host-1": "a.example.com"},
+							},
+						},
+					},
+				},
+				{
+					Name: "skipped",
+					When: "0",
+					Items: []ConfigItem{
+						{Name: "skipped_item", Type: "text", Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	values := &ConfigValues{
+		Spec: ConfigValuesSpec{
+			Values: map[string]ConfigValue{
+				"port":     {Value: "http"},
+				"username": {Value: "Admin"},
+				"password": {Value: "ZW5jcnlwdGVk"},
+				"tls":      {Value: "maybe"},
+				"mode":     {Value: "cloud"},
+				"host-2":   {Value: "B.example.com", RepeatableItem: "host"},
+			},
+		},
+	}
+
+	validationErrors, err := ValidateConfigValues(config, values)
+	require.NoError(t, err)
+
+	assert.Equal(t, []ConfigItemValidationError{
+		{Group: "database", Item: "hostname", ValueName: "hostname", Reason: ConfigValidationRequired, Message: "a value is required"},
+		{Group: "database", Item: "port", ValueName: "port", Reason: ConfigValidationRegex, Message: "port must be a number"},
+		{Group: "database", Item: "username", ValueName: "username", Reason: ConfigValidationRegex, Message: `value does not match pattern "^[a-z]+$"`},
+		{Group: "database", Item: "tls", ValueName: "tls", Reason: ConfigValidationInvalidBool, Message: `"maybe" is not a valid bool`},
+		{Group: "database", Item: "mode", ValueName: "mode", Reason: ConfigValidationInvalidOption, Message: `"cloud" is not one of the available options`},
+		{Group: "database", Item: "templated", ValueName: "templated", Reason: ConfigValidationRequired, Message: "a value is required"},
+		{Group: "hosts", Item: "host", ValueName: "host", Reason: ConfigValidationMinimumCount, Message: "at least 3 values are required, found 2"},
+		{Group: "hosts", Item: "host", ValueName: "host-2", Reason: ConfigValidationRegex, Message: "invalid host"},
+	}, validationErrors)
+
+	assert.Equal(t, "database/port: port must be a number", validationErrors[1].Error())
+	assert.Equal(t, "hosts/host (host-2): invalid host", validationErrors[7].Error())
+}
+
+func Test_ValidateConfigValues_Valid(t *testing.T) {
+	config := &Config{
+		Spec: ConfigSpec{
+			Groups: []ConfigGroup{
+				{
+					Name: "settings",
+					Items: []ConfigItem{
+						{Name: "hostname", Type: "text", Required: true},
+						{Name: "port", Type: "text", Required: true, Default: multitype.FromString("443")},
+						{Name: "tls", Type: "bool", Default: multitype.FromBool(true)},
+						{Name: "mode", Type: "select_one", Default: multitype.FromString("a"), Items: []ConfigChildItem{{Name: "a"}}},
+						{Name: "secret", Type: "password", Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	values := &ConfigValues{
+		Spec: ConfigValuesSpec{
+			Values: map[string]ConfigValue{
+				"hostname": {Value: "example.com"},
+				"secret":   {ValuePlaintext: "hunter2"},
+			},
+		},
+	}
+
+	validationErrors, err := ValidateConfigValues(config, values)
+	require.NoError(t, err)
+	assert.Empty(t, validationErrors)
+}
+
+func Test_ValidateConfigValues_EncryptedPassword(t *testing.T) {
+	config := &Config{
+		Spec: ConfigSpec{
+			Groups: []ConfigGroup{
+				{
+					Name: "settings",
+					Items: []ConfigItem{
+						{
+							Name:       "secret",
+							Type:       "password",
+							Required:   true,
+							Validation: &ConfigItemValidation{Regex: &RegexValidator{Pattern: "^.{8,}$", Message: "too short"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// the encrypted value can't be checked against the pattern
+	values := &ConfigValues{
+		Spec: ConfigValuesSpec{
+			Values: map[string]ConfigValue{
+				"secret": {Value: "ZW5jcnlwdGVk"},
+			},
+		},
+	}
+	validationErrors, err := ValidateConfigValues(config, values)
+	require.NoError(t, err)
+	assert.Empty(t, validationErrors)
+
+	// the plaintext is
+	values.Spec.Values["secret"] = ConfigValue{Value: "ZW5jcnlwdGVk", ValuePlaintext: "short"}
+	validationErrors, err = ValidateConfigValues(config, values)
+	require.NoError(t, err)
+	require.Len(t, validationErrors, 1)
+	assert.Equal(t, ConfigValidationRegex, validationErrors[0].Reason)
+}
+
+func Test_ValidateConfigValues_Template(t *testing.T) {
+	config := &Config{
+		Spec: ConfigSpec{
+			Groups: []ConfigGroup{
+				{
+					Name: "settings",
+					Items: []ConfigItem{
+						{
+							Name:    "mode",
+							Type:    "select_one",
+							Default: multitype.FromString(`repl{{ ConfigOption "default_mode" }}`),
+							Items:   []ConfigChildItem{{Name: "fast"}, {Name: "safe"}},
+						},
+						{
+							Name:       "port",
+							Type:       "text",
+							Validation: &ConfigItemValidation{Regex: &RegexValidator{Pattern: "^[0-9]+$"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	values := &ConfigValues{
+		Spec: ConfigValuesSpec{
+			Values: map[string]ConfigValue{
+				"port": {Value: `{{repl ConfigOption "base_port" }}`},
+			},
+		},
+	}
+
+	validationErrors, err := ValidateConfigValues(config, values)
+	require.NoError(t, err)
+	assert.Empty(t, validationErrors)
+}
+
+func Test_ValidateConfigValues_InvalidRegex(t *testing.T) {
+	config := &Config{
+		Spec: ConfigSpec{
+			Groups: []ConfigGroup{
+				{
+					Name: "settings",
+					Items: []ConfigItem{
+						{Name: "a", Type: "text", Validation: &ConfigItemValidation{Regex: &RegexValidator{Pattern: "("}}},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := ValidateConfigValues(config, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,202 @@
+package v1beta1
+
+import (
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kotskinds/multitype"
+	kotscrypto "github.com/replicatedhq/kotskinds/pkg/crypto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApplyConfigValues returns a copy of config with each item's Value, Default, Data and
+// Filename filled in from values. Items without an entry in values are unchanged.
+//
+// For each entry, ValuePlaintext takes precedence over Value. The Value of a password item
+// is encrypted, and is decrypted with keyring. keyring may be nil when no password values
+// need to be decrypted. Values of repeatable items are stored in the item's ValuesByGroup.
+func ApplyConfigValues(config *Config, values *ConfigValues, keyring kotscrypto.Keyring) (*Config, error) {
+	if config == nil {
+		return nil, errors.New("config is required")
+	}
+
+	applied := config.DeepCopy()
+	if values == nil {
+		return applied, nil
+	}
+
+	for g, group := range applied.Spec.Groups {
+		for i, item := range group.Items {
+			if !item.Kind().HasValue() {
+				continue
+			}
+
+			if item.Repeatable {
+				repeatValues, err := repeatableConfigValues(item, values, keyring)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to apply values for item %s", item.Name)
+				}
+				if len(repeatValues) > 0 {
+					if item.ValuesByGroup == nil {
+						item.ValuesByGroup = ValuesByGroup{}
+					}
+					item.ValuesByGroup[group.Name] = repeatValues
+					item.CountByGroup = map[string]int{}
+					for groupName, groupValues := range item.ValuesByGroup {
+						item.CountByGroup[groupName] = len(groupValues)
+					}
+				}
+				applied.Spec.Groups[g].Items[i] = item
+				continue
+			}
+
+			configValue, ok := values.Spec.Values[item.Name]
+			if !ok {
+				continue
+			}
+
+			value, err := configValuePlaintext(item, configValue, keyring)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to apply value for item %s", item.Name)
+			}
+			if value != "" {
+				item.Value = multitype.FromString(value)
+			}
+			if configValue.Default != "" {
+				item.Default = multitype.FromString(configValue.Default)
+			}
+
+			if item.Kind() == ConfigItemKindFile {
+				if configValue.Filename != "" {
+					item.Filename = configValue.Filename
+				}
+				if configValue.DataPlaintext != "" {
+					item.Data = configValue.DataPlaintext
+				} else if configValue.Data != "" {
+					item.Data = configValue.Data
+				}
+			}
+
+			applied.Spec.Groups[g].Items[i] = item
+		}
+	}
+
+	return applied, nil
+}
+
+// ExtractConfigValues is the reverse of ApplyConfigValues. It returns ConfigValues with an
+// entry for each item in config that has a value or default, and one for each value of
+// each repeatable item. Password values are returned unencrypted, in ValuePlaintext. Use
+// ConfigValues.EncryptSecrets to encrypt them.
+func ExtractConfigValues(config *Config) *ConfigValues {
+	values := &ConfigValues{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "kots.io/v1beta1",
+			Kind:       "ConfigValues",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: config.Name,
+		},
+		Spec: ConfigValuesSpec{
+			Values: map[string]ConfigValue{},
+		},
+	}
+
+	for _, group := range config.Spec.Groups {
+		for _, item := range group.Items {
+			if !item.Kind().HasValue() {
+				continue
+			}
+
+			if item.Repeatable {
+				for name, value := range item.ValuesByGroup[group.Name] {
+					configValue := ConfigValue{RepeatableItem: item.Name}
+					if item.Kind() == ConfigItemKindPassword {
+						configValue.ValuePlaintext = value
+					} else {
+						configValue.Value = value
+					}
+					values.Spec.Values[name] = configValue
+				}
+				continue
+			}
+
+			configValue := ConfigValue{
+				Default: item.Default.String(),
+			}
+			if !item.Value.IsEmpty() {
+				if item.Kind() == ConfigItemKindPassword {
+					configValue.ValuePlaintext = item.Value.String()
+				} else {
+					configValue.Value = item.Value.String()
+				}
+			}
+			if item.Kind() == ConfigItemKindFile {
+				configValue.Filename = item.Filename
+				configValue.Data = item.Data
+			}
+
+			if configValue == (ConfigValue{}) {
+				continue
+			}
+			values.Spec.Values[item.Name] = configValue
+		}
+	}
+
+	return values
+}
+
+// repeatableConfigValues returns the plaintext values in values that belong to the
+// repeatable item
+func repeatableConfigValues(item ConfigItem, values *ConfigValues, keyring kotscrypto.Keyring) (GroupValues, error) {
+	groupValues := GroupValues{}
+	for name, configValue := range values.Spec.Values {
+		if configValue.RepeatableItem != item.Name {
+			continue
+		}
+		value, err := configValuePlaintext(item, configValue, keyring)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read value %s", name)
+		}
+		groupValues[name] = value
+	}
+	return groupValues, nil
+}
+
+// configValuePlaintext returns the plaintext value of a ConfigValue, decrypting Value
+// when the item is a password and there is no ValuePlaintext
+func configValuePlaintext(item ConfigItem, configValue ConfigValue, keyring kotscrypto.Keyring) (string, error) {
+	if configValue.ValuePlaintext != "" {
+		return configValue.ValuePlaintext, nil
+	}
+	if configValue.Value == "" || item.Kind() != ConfigItemKindPassword {
+		return configValue.Value, nil
+	}
+	return decryptConfigValue(configValue.Value, keyring)
+}
+
+// decryptConfigValue decrypts a base64 encoded, encrypted value
+func decryptConfigValue(value string, keyring kotscrypto.Keyring) (string, error) {
+	if keyring == nil {
+		return "", errors.New("a keyring is required to decrypt values")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode value")
+	}
+	decrypted, err := keyring.Decrypt(decoded)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decrypt value")
+	}
+	return string(decrypted), nil
+}
+
+// encryptConfigValue encrypts a value and base64 encodes the result
+func encryptConfigValue(value string, keyring kotscrypto.Keyring) (string, error) {
+	if keyring == nil {
+		return "", errors.New("a keyring is required to encrypt values")
+	}
+
+	return base64.StdEncoding.EncodeToString(keyring.Encrypt([]byte(value))), nil
+}
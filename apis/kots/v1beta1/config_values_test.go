@@ -0,0 +1,127 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/replicatedhq/kotskinds/multitype"
+	kotscrypto "github.com/replicatedhq/kotskinds/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	testKey        = "wwYTl3RHaCirSqx7alC/hsRQXyycHDdGZZCyNMy9R01p5czC"
+	testCiphertext = "sNrI1egS1iLGesPDecd8G7WoNyE/KL7IFR6mYPzWwZLY5xCC"
+	testPlaintext  = "this is a test value"
+)
+
+func testApplyConfig() *Config {
+	return &Config{
+		ObjectMeta: metav1.ObjectMeta{Name: "app"},
+		Spec: ConfigSpec{
+			Groups: []ConfigGroup{
+				{
+					Name: "settings",
+					Items: []ConfigItem{
+						{Name: "heading", Type: "heading", Title: "Settings"},
+						{Name: "hostname", Type: "text", Default: multitype.FromString("localhost")},
+						{Name: "port", Type: "text", Default: multitype.FromString("443")},
+						{Name: "tls", Type: "bool", Default: multitype.FromBool(false)},
+						{Name: "password", Type: "password"},
+						{Name: "api_key", Type: "password"},
+						{Name: "cert", Type: "file"},
+						{Name: "untouched", Type: "text", Value: multitype.FromString("kept")},
+						{Name: "host", Type: "text", Repeatable: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+func Test_ApplyConfigValues(t *testing.T) {
+	keyring, err := kotscrypto.NewKeyring(testKey)
+	require.NoError(t, err)
+
+	values := &ConfigValues{
+		Spec: ConfigValuesSpec{
+			Values: map[string]ConfigValue{
+				"hostname": {Value: "example.com", Default: "generated"},
+				"port":     {Default: "8443"},
+				"tls":      {Value: "1"},
+				"password": {Value: testCiphertext},
+				"api_key":  {Value: "ignored", ValuePlaintext: "plain"},
+				"cert":     {Value: "Y2VydA==", Filename: "cert.pem", Data: "old", DataPlaintext: "data"},
+				"host-1":   {Value: "a.example.com", RepeatableItem: "host"},
+				"host-2":   {Value: "b.example.com", RepeatableItem: "host"},
+				"unknown":  {Value: "x"},
+			},
+		},
+	}
+
+	config := testApplyConfig()
+	applied, err := ApplyConfigValues(config, values, keyring)
+	require.NoError(t, err)
+
+	items := map[string]*ConfigItem{}
+	for i, item := range applied.Spec.Groups[0].Items {
+		items[item.Name] = &applied.Spec.Groups[0].Items[i]
+	}
+
+	assert.Equal(t, "example.com", items["hostname"].Value.String())
+	assert.Equal(t, "generated", items["hostname"].Default.String())
+	assert.True(t, items["port"].Value.IsEmpty())
+	assert.Equal(t, "8443", items["port"].StringValue())
+	tls, err := items["tls"].BoolValue()
+	require.NoError(t, err)
+	assert.True(t, tls)
+	assert.Equal(t, testPlaintext, items["password"].Value.String())
+	assert.Equal(t, "plain", items["api_key"].Value.String())
+	assert.Equal(t, "Y2VydA==", items["cert"].Value.String())
+	assert.Equal(t, "cert.pem", items["cert"].Filename)
+	assert.Equal(t, "data", items["cert"].Data)
+	assert.Equal(t, "kept", items["untouched"].Value.String())
+	assert.Equal(t, GroupValues{"host-1": "a.example.com", "host-2": "b.example.com"}, items["host"].ValuesByGroup["settings"])
+	assert.Equal(t, map[string]int{"settings": 2}, items["host"].CountByGroup)
+
+	// the input is not modified
+	assert.True(t, config.Spec.Groups[0].Items[1].Value.IsEmpty())
+
+	extracted := ExtractConfigValues(applied)
+	assert.Equal(t, "ConfigValues", extracted.Kind)
+	assert.Equal(t, "app", extracted.Name)
+	assert.Equal(t, map[string]ConfigValue{
+		"hostname":  {Value: "example.com", Default: "generated"},
+		"port":      {Default: "8443"},
+		"tls":       {Value: "1", Default: "0"},
+		"password":  {ValuePlaintext: testPlaintext},
+		"api_key":   {ValuePlaintext: "plain"},
+		"cert":      {Value: "Y2VydA==", Filename: "cert.pem", Data: "data"},
+		"untouched": {Value: "kept"},
+		"host-1":    {Value: "a.example.com", RepeatableItem: "host"},
+		"host-2":    {Value: "b.example.com", RepeatableItem: "host"},
+	}, extracted.Spec.Values)
+}
+
+func Test_ApplyConfigValues_DecryptErrors(t *testing.T) {
+	values := &ConfigValues{
+		Spec: ConfigValuesSpec{
+			Values: map[string]ConfigValue{
+				"password": {Value: testCiphertext},
+			},
+		},
+	}
+
+	_, err := ApplyConfigValues(testApplyConfig(), values, nil)
+	require.Error(t, err)
+
+	otherKeyring, err := kotscrypto.NewKeyring("dGhpcyBpcyBhIDI0IGJ5dGUga2V5ISEhbm9uY2UxMjM0NTY3")
+	require.NoError(t, err)
+	_, err = ApplyConfigValues(testApplyConfig(), values, otherKeyring)
+	require.Error(t, err)
+
+	applied, err := ApplyConfigValues(testApplyConfig(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, testApplyConfig(), applied)
+}
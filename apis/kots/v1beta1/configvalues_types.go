@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	"github.com/pkg/errors"
+	kotscrypto "github.com/replicatedhq/kotskinds/pkg/crypto"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -64,3 +66,94 @@ type ConfigValuesList struct {
 func init() {
 	SchemeBuilder.Register(&ConfigValues{}, &ConfigValuesList{})
 }
+
+// EncryptSecrets moves the values of password items from ValuePlaintext and DataPlaintext
+// to Value and Data, encrypting them with keyring. Items are looked up in config, values
+// of repeatable items use the type of the repeatable item, and values for items that are
+// not in config are left unchanged. Like StringValueOrEncrypted.EncryptValue, values that
+// are already encrypted are left as they are, so calling EncryptSecrets again is a no-op.
+func (v *ConfigValues) EncryptSecrets(config *Config, keyring kotscrypto.Keyring) error {
+	sensitive := sensitiveConfigValues(config, v)
+	for name, configValue := range v.Spec.Values {
+		if !sensitive[name] {
+			continue
+		}
+
+		if configValue.ValuePlaintext != "" {
+			encrypted, err := encryptConfigValue(configValue.ValuePlaintext, keyring)
+			if err != nil {
+				return errors.Wrapf(err, "failed to encrypt value %s", name)
+			}
+			configValue.Value = encrypted
+			configValue.ValuePlaintext = ""
+		}
+		if configValue.DataPlaintext != "" {
+			encrypted, err := encryptConfigValue(configValue.DataPlaintext, keyring)
+			if err != nil {
+				return errors.Wrapf(err, "failed to encrypt data %s", name)
+			}
+			configValue.Data = encrypted
+			configValue.DataPlaintext = ""
+		}
+
+		v.Spec.Values[name] = configValue
+	}
+
+	return nil
+}
+
+// DecryptSecrets is the reverse of EncryptSecrets. It decrypts the Value and Data of
+// password items into ValuePlaintext and DataPlaintext. Values that already have a
+// plaintext are not decrypted, so calling DecryptSecrets again is a no-op.
+func (v *ConfigValues) DecryptSecrets(config *Config, keyring kotscrypto.Keyring) error {
+	sensitive := sensitiveConfigValues(config, v)
+	for name, configValue := range v.Spec.Values {
+		if !sensitive[name] {
+			continue
+		}
+
+		if configValue.ValuePlaintext == "" && configValue.Value != "" {
+			decrypted, err := decryptConfigValue(configValue.Value, keyring)
+			if err != nil {
+				return errors.Wrapf(err, "failed to decrypt value %s", name)
+			}
+			configValue.ValuePlaintext = decrypted
+		}
+		configValue.Value = ""
+
+		if configValue.DataPlaintext == "" && configValue.Data != "" {
+			decrypted, err := decryptConfigValue(configValue.Data, keyring)
+			if err != nil {
+				return errors.Wrapf(err, "failed to decrypt data %s", name)
+			}
+			configValue.DataPlaintext = decrypted
+		}
+		configValue.Data = ""
+
+		v.Spec.Values[name] = configValue
+	}
+
+	return nil
+}
+
+// sensitiveConfigValues returns the names of the values that belong to password items
+func sensitiveConfigValues(config *Config, values *ConfigValues) map[string]bool {
+	passwordItems := map[string]bool{}
+	if config != nil {
+		for _, group := range config.Spec.Groups {
+			for _, item := range group.Items {
+				if item.Kind() == ConfigItemKindPassword {
+					passwordItems[item.Name] = true
+				}
+			}
+		}
+	}
+
+	sensitive := map[string]bool{}
+	for name, configValue := range values.Spec.Values {
+		if passwordItems[name] || passwordItems[configValue.RepeatableItem] {
+			sensitive[name] = true
+		}
+	}
+	return sensitive
+}
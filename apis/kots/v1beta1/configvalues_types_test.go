@@ -0,0 +1,88 @@
+package v1beta1
+
+import (
+	"testing"
+
+	kotscrypto "github.com/replicatedhq/kotskinds/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConfigValuesEncryptDecryptSecrets(t *testing.T) {
+	keyring, err := kotscrypto.NewKeyring(testKey)
+	require.NoError(t, err)
+
+	config := &Config{
+		Spec: ConfigSpec{
+			Groups: []ConfigGroup{
+				{
+					Name: "settings",
+					Items: []ConfigItem{
+						{Name: "hostname", Type: "text"},
+						{Name: "password", Type: "password"},
+						{Name: "encrypted", Type: "password"},
+						{Name: "token", Type: "password", Repeatable: true},
+					},
+				},
+			},
+		},
+	}
+
+	values := &ConfigValues{
+		Spec: ConfigValuesSpec{
+			Values: map[string]ConfigValue{
+				"hostname":  {ValuePlaintext: "example.com"},
+				"password":  {ValuePlaintext: testPlaintext},
+				"encrypted": {Value: testCiphertext},
+				"token-1":   {ValuePlaintext: testPlaintext, DataPlaintext: testPlaintext, RepeatableItem: "token"},
+				"unknown":   {ValuePlaintext: "x"},
+			},
+		},
+	}
+
+	require.NoError(t, values.EncryptSecrets(config, keyring))
+	encrypted := map[string]ConfigValue{
+		"hostname":  {ValuePlaintext: "example.com"},
+		"password":  {Value: testCiphertext},
+		"encrypted": {Value: testCiphertext},
+		"token-1":   {Value: testCiphertext, Data: testCiphertext, RepeatableItem: "token"},
+		"unknown":   {ValuePlaintext: "x"},
+	}
+	assert.Equal(t, encrypted, values.Spec.Values)
+
+	// encrypting again is a no-op
+	require.NoError(t, values.EncryptSecrets(config, keyring))
+	assert.Equal(t, encrypted, values.Spec.Values)
+
+	require.NoError(t, values.DecryptSecrets(config, keyring))
+	decrypted := map[string]ConfigValue{
+		"hostname":  {ValuePlaintext: "example.com"},
+		"password":  {ValuePlaintext: testPlaintext},
+		"encrypted": {ValuePlaintext: testPlaintext},
+		"token-1":   {ValuePlaintext: testPlaintext, DataPlaintext: testPlaintext, RepeatableItem: "token"},
+		"unknown":   {ValuePlaintext: "x"},
+	}
+	assert.Equal(t, decrypted, values.Spec.Values)
+
+	// decrypting again is a no-op, and doesn't need a keyring
+	require.NoError(t, values.DecryptSecrets(config, nil))
+	assert.Equal(t, decrypted, values.Spec.Values)
+}
+
+func Test_ConfigValuesSecretsErrors(t *testing.T) {
+	config := &Config{
+		Spec: ConfigSpec{
+			Groups: []ConfigGroup{
+				{Name: "settings", Items: []ConfigItem{{Name: "password", Type: "password"}}},
+			},
+		},
+	}
+
+	values := &ConfigValues{Spec: ConfigValuesSpec{Values: map[string]ConfigValue{"password": {ValuePlaintext: "a"}}}}
+	require.Error(t, values.EncryptSecrets(config, nil))
+
+	values = &ConfigValues{Spec: ConfigValuesSpec{Values: map[string]ConfigValue{"password": {Value: "not encrypted"}}}}
+	keyring, err := kotscrypto.NewKeyring(testKey)
+	require.NoError(t, err)
+	require.Error(t, values.DecryptSecrets(config, keyring))
+}
@@ -1,6 +1,7 @@
 package v1beta1
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -267,6 +268,14 @@ func Test_MappedChartValueGetValue(t *testing.T) {
 			},
 			expected: float64(42),
 		},
+		{
+			name: "int",
+			mappedChartValue: MappedChartValue{
+				intValue:  9007199254740993,
+				valueType: "int",
+			},
+			expected: int64(9007199254740993),
+		},
 		{
 			name: "children",
 			mappedChartValue: MappedChartValue{
@@ -829,3 +838,105 @@ func TestHelmChart_GetBuilderValues(t *testing.T) {
 		})
 	}
 }
+
+func Test_MappedChartValueNumberPrecision(t *testing.T) {
+	tests := []struct {
+		name          string
+		json          string
+		expectedType  string
+		expectedValue interface{}
+	}{
+		{
+			name:          "small integer",
+			json:          `8080`,
+			expectedType:  "int",
+			expectedValue: json.Number("8080"),
+		},
+		{
+			name:          "integer above 2^53",
+			json:          `9007199254740993`,
+			expectedType:  "int",
+			expectedValue: json.Number("9007199254740993"),
+		},
+		{
+			name:          "negative integer",
+			json:          `-1000000`,
+			expectedType:  "int",
+			expectedValue: json.Number("-1000000"),
+		},
+		{
+			name:          "float",
+			json:          `0.25`,
+			expectedType:  "float",
+			expectedValue: json.Number("0.25"),
+		},
+		{
+			name:          "exponent is a float",
+			json:          `1e+21`,
+			expectedType:  "float",
+			expectedValue: json.Number("1e+21"),
+		},
+		{
+			name:          "float written as an integer",
+			json:          `2.0`,
+			expectedType:  "float",
+			expectedValue: json.Number("2.0"),
+		},
+		{
+			name:          "integer overflowing int64 keeps its literal",
+			json:          `18446744073709551616`,
+			expectedType:  "number",
+			expectedValue: json.Number("18446744073709551616"),
+		},
+		{
+			name:          "float overflowing float64 keeps its literal",
+			json:          `1e400`,
+			expectedType:  "number",
+			expectedValue: json.Number("1e400"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := require.New(t)
+
+			value := MappedChartValue{}
+			req.NoError(value.UnmarshalJSON([]byte(test.json)))
+			req.Equal(test.expectedType, value.valueType)
+
+			built, err := value.getBuiltValue()
+			req.NoError(err)
+			req.Equal(test.expectedValue, built)
+
+			marshalled, err := value.MarshalJSON()
+			req.NoError(err)
+			req.Equal(test.json, string(marshalled))
+		})
+	}
+}
+
+func Test_GetHelmValuesNumberPrecision(t *testing.T) {
+	req := require.New(t)
+
+	spec := HelmChartSpec{}
+	req.NoError(json.Unmarshal([]byte(`{"values":{"bytes":1000000,"id":123456789012345678,"ratio":0.5,"whole":2.0,"nested":{"ports":[80,443]}}}`), &spec))
+
+	values, err := spec.GetHelmValues(spec.Values)
+	req.NoError(err)
+	req.Equal(map[string]interface{}{
+		"bytes": json.Number("1000000"),
+		"id":    json.Number("123456789012345678"),
+		"ratio": json.Number("0.5"),
+		"whole": json.Number("2.0"),
+		"nested": map[string]interface{}{
+			"ports": []interface{}{json.Number("80"), json.Number("443")},
+		},
+	}, values)
+
+	marshalled, err := json.Marshal(spec.Values)
+	req.NoError(err)
+	req.JSONEq(`{"bytes":1000000,"id":123456789012345678,"ratio":0.5,"whole":2.0,"nested":{"ports":[80,443]}}`, string(marshalled))
+	req.Contains(string(marshalled), `"whole":2.0`)
+	req.Contains(string(marshalled), `"bytes":1000000`)
+	req.Contains(string(marshalled), `"id":123456789012345678`)
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2019 Replicated, Inc..
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kotskinds/apis/kots/internal/yamlnode"
+	"go.yaml.in/yaml/v3"
+)
+
+// The YAML hooks in this file exist only to keep the key order and comments of helm values.
+// sigs.k8s.io/yaml and the k8s UniversalDeserializer convert YAML to JSON through Go maps before
+// any UnmarshalJSON runs, so that information is gone by the time MappedChartValue sees it. When
+// a HelmChart is decoded with go.yaml.in/yaml/v3 instead, the hooks below record the source order
+// and comments of spec.values so that MarshalYAML and RenderValuesYAML can emit them unchanged.
+// Every other field is still decoded by the JSON hooks.
+
+// UnmarshalYAML decodes a HelmChart with go.yaml.in/yaml/v3, preserving the key order and
+// comments of spec.values.
+func (h *HelmChart) UnmarshalYAML(node *yaml.Node) error {
+	type helmChartAlias HelmChart
+	if err := yamlnode.UnmarshalAsJSON(node, (*helmChartAlias)(h)); err != nil {
+		return err
+	}
+
+	if specNode := yamlnode.MappingValue(yamlnode.Resolve(node), "spec"); specNode != nil {
+		if err := h.Spec.unmarshalValuesYAML(yamlnode.MappingValue(yamlnode.Resolve(specNode), "values")); err != nil {
+			return errors.Wrap(err, "failed to unmarshal spec values")
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalYAML decodes a HelmChartSpec with go.yaml.in/yaml/v3, preserving the key order and
+// comments of values.
+func (h *HelmChartSpec) UnmarshalYAML(node *yaml.Node) error {
+	type helmChartSpecAlias HelmChartSpec
+	if err := yamlnode.UnmarshalAsJSON(node, (*helmChartSpecAlias)(h)); err != nil {
+		return err
+	}
+
+	if err := h.unmarshalValuesYAML(yamlnode.MappingValue(yamlnode.Resolve(node), "values")); err != nil {
+		return errors.Wrap(err, "failed to unmarshal values")
+	}
+
+	return nil
+}
+
+func (h *HelmChartSpec) unmarshalValuesYAML(node *yaml.Node) error {
+	h.valuesKeys = nil
+	if node == nil {
+		return nil
+	}
+
+	node = yamlnode.Resolve(node)
+	if node.Kind != yaml.MappingNode || yamlnode.HasMergeKey(node) {
+		// values are still decoded, only without order and comments
+		return nil
+	}
+
+	values := make(map[string]MappedChartValue, len(node.Content)/2)
+	keys := make([]string, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+		value := MappedChartValue{}
+		if err := value.UnmarshalYAML(valueNode); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal value at %s", keyNode.Value)
+		}
+		value.keyComments = yamlnode.CommentsFromNode(keyNode)
+
+		if _, exists := values[keyNode.Value]; !exists {
+			keys = append(keys, keyNode.Value)
+		}
+		values[keyNode.Value] = value
+	}
+
+	h.Values = values
+	h.valuesKeys = keys
+	return nil
+}
+
+// RenderValuesYAML returns spec.values as a YAML document. Keys are emitted in the order they
+// appeared in the source document, and comments are kept, when the HelmChart was decoded with
+// go.yaml.in/yaml/v3. Keys with no recorded order are emitted after the others, sorted. Repl
+// templates are emitted as-is.
+func (h *HelmChartSpec) RenderValuesYAML() ([]byte, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	keys := make([]string, 0, len(h.Values))
+	for k := range h.Values {
+		keys = append(keys, k)
+	}
+
+	for _, k := range yamlnode.OrderedKeys(h.valuesKeys, keys) {
+		value := h.Values[k]
+		keyNode, valueNode, err := value.toYAMLKeyValue(k)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to render value at %s", k)
+		}
+		root.Content = append(root.Content, keyNode, valueNode)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, errors.Wrap(err, "failed to encode values")
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close encoder")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalYAML decodes a MappedChartValue with go.yaml.in/yaml/v3, preserving the order of
+// mapping keys and any comments.
+func (m *MappedChartValue) UnmarshalYAML(node *yaml.Node) error {
+	node = yamlnode.Resolve(node)
+	*m = MappedChartValue{valueComments: yamlnode.CommentsFromNode(node)}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if yamlnode.HasMergeKey(node) {
+			// merge keys can't be represented in order, fall back to the JSON decoding
+			comments := m.valueComments
+			if err := yamlnode.UnmarshalAsJSON(node, m); err != nil {
+				return err
+			}
+			m.valueComments = comments
+			return nil
+		}
+
+		m.valueType = "children"
+		m.children = make(map[string]*MappedChartValue, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+			child := &MappedChartValue{}
+			if err := child.UnmarshalYAML(valueNode); err != nil {
+				return errors.Wrapf(err, "failed to unmarshal child %s", keyNode.Value)
+			}
+			child.keyComments = yamlnode.CommentsFromNode(keyNode)
+
+			if _, exists := m.children[keyNode.Value]; !exists {
+				m.keys = append(m.keys, keyNode.Value)
+			}
+			m.children[keyNode.Value] = child
+		}
+		return nil
+
+	case yaml.SequenceNode:
+		m.valueType = "array"
+		m.array = []*MappedChartValue{}
+		for i, itemNode := range node.Content {
+			item := &MappedChartValue{}
+			if err := item.UnmarshalYAML(itemNode); err != nil {
+				return errors.Wrapf(err, "failed to unmarshal child %d", i)
+			}
+			m.array = append(m.array, item)
+		}
+		return nil
+
+	case yaml.ScalarNode:
+		// numbers are decoded from the node itself so that they keep the literal they were
+		// written as, e.g. 2.0 rather than 2. Numbers written in a form JSON does not have,
+		// such as 0x1F, are converted to an int64 or float64.
+		if number, ok := yamlnode.NumberLiteral(node); ok {
+			m.setNumber(number)
+			return nil
+		}
+		switch node.Tag {
+		case "!!int":
+			var i int64
+			if err := node.Decode(&i); err == nil {
+				m.intValue = i
+				m.valueType = "int"
+				return nil
+			}
+		case "!!float":
+			var f float64
+			if err := node.Decode(&f); err == nil {
+				m.floatValue = f
+				m.valueType = "float"
+				return nil
+			}
+		}
+
+		comments := m.valueComments
+		if err := yamlnode.UnmarshalAsJSON(node, m); err != nil {
+			return err
+		}
+		m.valueComments = comments
+		return nil
+
+	default:
+		return errors.Errorf("unsupported yaml node kind %d", node.Kind)
+	}
+}
+
+// MarshalYAML returns the value as a go.yaml.in/yaml/v3 node, keeping the source key order and
+// comments recorded by UnmarshalYAML.
+func (m MappedChartValue) MarshalYAML() (interface{}, error) {
+	return m.toYAMLNode()
+}
+
+func (m *MappedChartValue) toYAMLKeyValue(key string) (*yaml.Node, *yaml.Node, error) {
+	keyNode := &yaml.Node{}
+	if err := keyNode.Encode(key); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to encode key")
+	}
+	m.keyComments.ApplyTo(keyNode)
+
+	valueNode, err := m.toYAMLNode()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return keyNode, valueNode, nil
+}
+
+func (m *MappedChartValue) toYAMLNode() (*yaml.Node, error) {
+	var node *yaml.Node
+
+	switch m.valueType {
+	case "children":
+		keys := make([]string, 0, len(m.children))
+		for k := range m.children {
+			keys = append(keys, k)
+		}
+
+		node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range yamlnode.OrderedKeys(m.keys, keys) {
+			keyNode, valueNode, err := m.children[k].toYAMLKeyValue(k)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to build value of child %s", k)
+			}
+			node.Content = append(node.Content, keyNode, valueNode)
+		}
+
+	case "array":
+		node = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for i, v := range m.array {
+			itemNode, err := v.toYAMLNode()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to build value of child %d", i)
+			}
+			node.Content = append(node.Content, itemNode)
+		}
+
+	default:
+		if m.numberValue != "" {
+			node = yamlnode.NumberNode(m.numberValue)
+			break
+		}
+
+		built, err := m.getBuiltValue()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get built value")
+		}
+		node = &yaml.Node{}
+		if err := node.Encode(built); err != nil {
+			return nil, errors.Wrap(err, "failed to encode value")
+		}
+	}
+
+	m.valueComments.ApplyTo(node)
+	return node, nil
+}
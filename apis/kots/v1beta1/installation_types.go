@@ -46,6 +46,12 @@ type InstallationImage struct {
 type InstallationYAMLError struct {
 	Path  string `json:"path"`
 	Error string `json:"error,omitempty"`
+	// DocumentIndex is the zero-based index of the document in a multi-document file
+	DocumentIndex int `json:"documentIndex,omitempty"`
+	// Line is the one-based line of the error in the file, or zero when it is not known
+	Line int `json:"line,omitempty"`
+	// Column is the one-based column of the error in the line, or zero when it is not known
+	Column int `json:"column,omitempty"`
 }
 
 // InstallationStatus defines the observed state of Installation
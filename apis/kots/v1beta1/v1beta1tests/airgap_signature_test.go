@@ -0,0 +1,180 @@
+package v1beta1tests
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	_ "embed"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	kotscrypto "github.com/replicatedhq/kotskinds/pkg/crypto"
+	"github.com/replicatedhq/kotskinds/pkg/licensewrapper"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+// The fixture was signed with openssl rather than with this package:
+//
+//	openssl dgst -sha256 -sigopt rsa_padding_mode:pss -sigopt rsa_pss_saltlen:32 \
+//	  -sign app-key.pem -out sig.bin airgap-data.json
+//
+// spec.signature is the base64 encoded {"airgapData": ..., "signature": ...} envelope of
+// airgap-data.json and sig.bin. Only the public key is kept.
+
+//go:embed testdata/airgap-signature/airgap.yaml
+var signedAirgapYAML []byte
+
+//go:embed testdata/airgap-signature/app-key.pub
+var signedAirgapPublicKey string
+
+// testAppLicense returns a license whose signature embeds publicKeyPEM as the app key
+func testAppLicense(t *testing.T, publicKeyPEM string) licensewrapper.LicenseWrapper {
+	t.Helper()
+
+	innerSig, err := json.Marshal(kotscrypto.InnerSignature{
+		PublicKey: publicKeyPEM,
+	})
+	require.NoError(t, err)
+	outerSig, err := json.Marshal(kotscrypto.OuterSignature{
+		LicenseData:    []byte("{}"),
+		InnerSignature: innerSig,
+	})
+	require.NoError(t, err)
+
+	return licensewrapper.LicenseWrapper{
+		V1: &v1beta1.License{
+			Spec: v1beta1.LicenseSpec{
+				Signature: outerSig,
+				AppSlug:   "my-app",
+				ChannelID: "stable-id",
+				Channels: []v1beta1.Channel{
+					{ChannelID: "stable-id"},
+					{ChannelID: "beta-id"},
+				},
+			},
+		},
+	}
+}
+
+func publicKeyPEM(t *testing.T, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}))
+}
+
+func signedAirgap(t *testing.T) *v1beta1.Airgap {
+	t.Helper()
+
+	airgap := &v1beta1.Airgap{}
+	require.NoError(t, yaml.Unmarshal(signedAirgapYAML, airgap))
+	return airgap
+}
+
+// signAirgap signs the spec of airgap with appKey, for the checks that follow the signature
+func signAirgap(t *testing.T, appKey *rsa.PrivateKey, airgap *v1beta1.Airgap) {
+	t.Helper()
+
+	spec := airgap.Spec
+	spec.Signature = nil
+	data, err := json.Marshal(spec)
+	require.NoError(t, err)
+
+	hashed := sha256.Sum256(data)
+	signature, err := rsa.SignPSS(rand.Reader, appKey, crypto.SHA256, hashed[:], nil)
+	require.NoError(t, err)
+
+	airgap.Spec.Signature, err = json.Marshal(v1beta1.AirgapSignature{AirgapData: data, Signature: signature})
+	require.NoError(t, err)
+}
+
+func TestAirgapValidateSignature(t *testing.T) {
+	license := testAppLicense(t, signedAirgapPublicKey)
+
+	t.Run("valid", func(t *testing.T) {
+		require.NoError(t, signedAirgap(t).ValidateSignature(license))
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		airgap := signedAirgap(t)
+		airgap.Spec.Signature = nil
+
+		err := airgap.ValidateSignature(license)
+		require.True(t, v1beta1.IsAirgapSignatureMissingError(err))
+	})
+
+	t.Run("metadata does not match the signed data", func(t *testing.T) {
+		airgap := signedAirgap(t)
+		airgap.Spec.SavedImages = append(airgap.Spec.SavedImages, "evil:latest")
+
+		err := airgap.ValidateSignature(license)
+		require.True(t, v1beta1.IsAirgapSignatureInvalidError(err))
+		require.Contains(t, err.Error(), "savedImages does not match the signed airgap data")
+	})
+
+	t.Run("tampered signed data", func(t *testing.T) {
+		airgap := signedAirgap(t)
+		var signature v1beta1.AirgapSignature
+		require.NoError(t, json.Unmarshal(airgap.Spec.Signature, &signature))
+		signature.AirgapData = []byte(strings.Replace(string(signature.AirgapData), "nginx:1.25", "evil:1.25", 1))
+		airgap.Spec.SavedImages = []string{"evil:1.25"}
+		var err error
+		airgap.Spec.Signature, err = json.Marshal(signature)
+		require.NoError(t, err)
+
+		err = airgap.ValidateSignature(license)
+		require.True(t, v1beta1.IsAirgapSignatureInvalidError(err))
+	})
+
+	t.Run("malformed signature", func(t *testing.T) {
+		airgap := signedAirgap(t)
+		airgap.Spec.Signature = []byte("not json")
+
+		err := airgap.ValidateSignature(license)
+		require.True(t, v1beta1.IsAirgapSignatureInvalidError(err))
+	})
+
+	t.Run("signed with another key", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+
+		err = signedAirgap(t).ValidateSignature(testAppLicense(t, publicKeyPEM(t, otherKey)))
+		require.True(t, v1beta1.IsAirgapSignatureInvalidError(err))
+	})
+
+	appKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	appLicense := testAppLicense(t, publicKeyPEM(t, appKey))
+
+	t.Run("different app", func(t *testing.T) {
+		airgap := signedAirgap(t)
+		airgap.Spec.AppSlug = "other-app"
+		signAirgap(t, appKey, airgap)
+
+		err := airgap.ValidateSignature(appLicense)
+		require.True(t, v1beta1.IsAirgapAppSlugMismatchError(err))
+		var target *v1beta1.AirgapAppSlugMismatchError
+		require.ErrorAs(t, err, &target)
+		require.Equal(t, "other-app", target.AirgapAppSlug)
+		require.Equal(t, "my-app", target.LicenseAppSlug)
+	})
+
+	t.Run("different channel", func(t *testing.T) {
+		airgap := signedAirgap(t)
+		airgap.Spec.ChannelID = "unstable-id"
+		signAirgap(t, appKey, airgap)
+
+		err := airgap.ValidateSignature(appLicense)
+		require.True(t, v1beta1.IsAirgapChannelMismatchError(err))
+		var target *v1beta1.AirgapChannelMismatchError
+		require.ErrorAs(t, err, &target)
+		require.Equal(t, []string{"stable-id", "beta-id"}, target.LicenseChannelIDs)
+	})
+}
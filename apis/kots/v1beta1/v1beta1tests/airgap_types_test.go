@@ -1,6 +1,7 @@
 package v1beta1tests
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
@@ -80,9 +81,72 @@ func TestEmbeddedClusterArtifactsTotal(t *testing.T) {
 			},
 			want: 9,
 		},
+		{
+			name: "multiple architectures",
+			airgap: &v1beta1.Airgap{
+				Spec: v1beta1.AirgapSpec{
+					EmbeddedClusterArtifacts: &v1beta1.EmbeddedClusterArtifacts{
+						Charts:      "charts",
+						ImagesAmd64: "images-amd64",
+						BinaryAmd64: "binary-amd64",
+						Architectures: map[string]v1beta1.EmbeddedClusterArchArtifacts{
+							"amd64": {Images: "images-amd64", Binary: "binary-amd64"},
+							"arm64": {Images: "images-arm64", Binary: "binary-arm64"},
+						},
+					},
+				},
+			},
+			want: 5,
+		},
+		{
+			name: "architectures only",
+			airgap: &v1beta1.Airgap{
+				Spec: v1beta1.AirgapSpec{
+					EmbeddedClusterArtifacts: &v1beta1.EmbeddedClusterArtifacts{
+						Architectures: map[string]v1beta1.EmbeddedClusterArchArtifacts{
+							"arm64": {Images: "images-arm64", Binary: "binary-arm64"},
+						},
+					},
+				},
+			},
+			want: 2,
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			require.Equal(t, tt.airgap.Spec.EmbeddedClusterArtifacts.Total(), tt.want)
 		})
 	}
 }
+
+func TestEmbeddedClusterArtifactsArchitectures(t *testing.T) {
+	legacy := []byte(`{"imagesAmd64":"images-amd64.tar","binaryAmd64":"my-app"}`)
+	artifacts := &v1beta1.EmbeddedClusterArtifacts{}
+	require.NoError(t, json.Unmarshal(legacy, artifacts))
+	require.Equal(t, []string{"amd64"}, artifacts.Arches())
+	require.Equal(t, "images-amd64.tar", artifacts.ImagesFor("amd64"))
+	require.Equal(t, "my-app", artifacts.BinaryFor("amd64"))
+	require.Equal(t, "", artifacts.BinaryFor("arm64"))
+
+	multiArch := []byte(`{
+		"imagesAmd64": "images-amd64.tar",
+		"architectures": {
+			"amd64": {"binary": "amd64/my-app"},
+			"arm64": {"images": "images-arm64.tar", "binary": "arm64/my-app"}
+		}
+	}`)
+	artifacts = &v1beta1.EmbeddedClusterArtifacts{}
+	require.NoError(t, json.Unmarshal(multiArch, artifacts))
+	require.Equal(t, []string{"amd64", "arm64"}, artifacts.Arches())
+	require.Equal(t, "images-amd64.tar", artifacts.ImagesFor("amd64"))
+	require.Equal(t, "amd64/my-app", artifacts.BinaryFor("amd64"))
+	require.Equal(t, "images-arm64.tar", artifacts.ImagesFor("arm64"))
+	require.Equal(t, "arm64/my-app", artifacts.BinaryFor("arm64"))
+
+	copied := artifacts.DeepCopy()
+	copied.Architectures["arm64"] = v1beta1.EmbeddedClusterArchArtifacts{}
+	require.Equal(t, "arm64/my-app", artifacts.BinaryFor("arm64"))
+
+	var empty *v1beta1.EmbeddedClusterArtifacts
+	require.Equal(t, "", empty.BinaryFor("amd64"))
+	require.Equal(t, []string{}, empty.Arches())
+}
@@ -0,0 +1,174 @@
+package v1beta1tests
+
+import (
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CheckKotsCompatibility(t *testing.T) {
+	tests := []struct {
+		name           string
+		minKotsVersion string
+		targetVersion  string
+		runningVersion string
+		wantStatus     kotsv1beta1.KotsCompatibilityStatus
+		wantReason     string
+	}{
+		{
+			name:           "no constraints",
+			runningVersion: "1.100.0",
+			wantStatus:     kotsv1beta1.KotsCompatibilitySupported,
+			wantReason:     "KOTS 1.100.0 is supported by this application",
+		},
+		{
+			name:           "between minimum and target",
+			minKotsVersion: "1.60.0",
+			targetVersion:  "1.100.0",
+			runningVersion: "v1.80.2",
+			wantStatus:     kotsv1beta1.KotsCompatibilitySupported,
+		},
+		{
+			name:           "equal to minimum and target",
+			minKotsVersion: "v1.60.0",
+			targetVersion:  "1.60.0",
+			runningVersion: "1.60.0",
+			wantStatus:     kotsv1beta1.KotsCompatibilitySupported,
+		},
+		{
+			name:           "below minimum",
+			minKotsVersion: "1.60.0",
+			runningVersion: "1.59.9",
+			wantStatus:     kotsv1beta1.KotsCompatibilityBelowMinimum,
+			wantReason:     "KOTS 1.59.9 is older than the minimum version 1.60.0 of this application",
+		},
+		{
+			name:           "prerelease of minimum",
+			minKotsVersion: "1.60.0",
+			runningVersion: "1.60.0-beta.1",
+			wantStatus:     kotsv1beta1.KotsCompatibilityBelowMinimum,
+		},
+		{
+			name:           "prerelease above minimum",
+			minKotsVersion: "1.59.0",
+			runningVersion: "1.60.0-beta.1",
+			wantStatus:     kotsv1beta1.KotsCompatibilitySupported,
+		},
+		{
+			name:           "above target",
+			targetVersion:  "v1.70",
+			runningVersion: "v1.71.0",
+			wantStatus:     kotsv1beta1.KotsCompatibilityAboveTarget,
+			wantReason:     "KOTS v1.71.0 is newer than the target version v1.70 of this application",
+		},
+		{
+			name:           "partial target covers its patches",
+			targetVersion:  "v1.70",
+			runningVersion: "1.70.4",
+			wantStatus:     kotsv1beta1.KotsCompatibilitySupported,
+		},
+		{
+			name:           "wildcard target",
+			targetVersion:  "1.70.x",
+			runningVersion: "1.70.9",
+			wantStatus:     kotsv1beta1.KotsCompatibilitySupported,
+		},
+		{
+			name:           "minimum range",
+			minKotsVersion: ">= 1.60.0 < 2.0.0",
+			runningVersion: "2.1.0",
+			wantStatus:     kotsv1beta1.KotsCompatibilityBelowMinimum,
+			wantReason:     `KOTS 2.1.0 is outside the minimum version range ">= 1.60.0 < 2.0.0" of this application`,
+		},
+		{
+			name:           "target range",
+			targetVersion:  "~1.60 || ^2.0",
+			runningVersion: "2.4.0",
+			wantStatus:     kotsv1beta1.KotsCompatibilitySupported,
+		},
+		{
+			name:           "outside target range",
+			targetVersion:  "~1.60",
+			runningVersion: "1.61.0",
+			wantStatus:     kotsv1beta1.KotsCompatibilityAboveTarget,
+		},
+		{
+			name:           "below minimum and above target",
+			minKotsVersion: "1.60.0",
+			targetVersion:  "1.50.0",
+			runningVersion: "1.55.0",
+			wantStatus:     kotsv1beta1.KotsCompatibilityBelowMinimum,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := &kotsv1beta1.Application{
+				Spec: kotsv1beta1.ApplicationSpec{
+					MinKotsVersion:    test.minKotsVersion,
+					TargetKotsVersion: test.targetVersion,
+				},
+			}
+			result, err := app.CheckKotsCompatibility(test.runningVersion)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantStatus, result.Status)
+			assert.Equal(t, test.wantStatus == kotsv1beta1.KotsCompatibilitySupported, result.IsSupported())
+			if test.wantReason != "" {
+				assert.Equal(t, test.wantReason, result.Reason)
+			}
+			switch test.wantStatus {
+			case kotsv1beta1.KotsCompatibilityBelowMinimum:
+				assert.Equal(t, test.minKotsVersion, result.Constraint)
+			case kotsv1beta1.KotsCompatibilityAboveTarget:
+				assert.Equal(t, test.targetVersion, result.Constraint)
+			default:
+				assert.Empty(t, result.Constraint)
+			}
+		})
+	}
+}
+
+func Test_CheckKotsCompatibilityInvalid(t *testing.T) {
+	tests := []struct {
+		name           string
+		minKotsVersion string
+		targetVersion  string
+		runningVersion string
+		wantField      string
+	}{
+		{
+			name:           "malformed running version",
+			minKotsVersion: "1.60.0",
+			runningVersion: "latest",
+		},
+		{
+			name:           "malformed minimum",
+			minKotsVersion: "1.60.0.1",
+			runningVersion: "1.60.0",
+			wantField:      "minKotsVersion",
+		},
+		{
+			name:           "incomplete target range",
+			targetVersion:  ">= 1.60.0 <",
+			runningVersion: "1.60.0",
+			wantField:      "targetKotsVersion",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := &kotsv1beta1.Application{
+				Spec: kotsv1beta1.ApplicationSpec{
+					MinKotsVersion:    test.minKotsVersion,
+					TargetKotsVersion: test.targetVersion,
+				},
+			}
+			_, err := app.CheckKotsCompatibility(test.runningVersion)
+			var invalidErr *kotsv1beta1.InvalidKotsVersionError
+			require.ErrorAs(t, err, &invalidErr)
+			assert.Equal(t, test.wantField, invalidErr.Field)
+		})
+	}
+}
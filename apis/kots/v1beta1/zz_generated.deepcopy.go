@@ -766,6 +766,21 @@ func (in *DexConnectorsSource) DeepCopy() *DexConnectorsSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EmbeddedClusterArchArtifacts) DeepCopyInto(out *EmbeddedClusterArchArtifacts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmbeddedClusterArchArtifacts.
+func (in *EmbeddedClusterArchArtifacts) DeepCopy() *EmbeddedClusterArchArtifacts {
+	if in == nil {
+		return nil
+	}
+	out := new(EmbeddedClusterArchArtifacts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EmbeddedClusterArtifacts) DeepCopyInto(out *EmbeddedClusterArtifacts) {
 	*out = *in
@@ -777,6 +792,13 @@ func (in *EmbeddedClusterArtifacts) DeepCopyInto(out *EmbeddedClusterArtifacts)
 			(*out)[key] = val
 		}
 	}
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make(map[string]EmbeddedClusterArchArtifacts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmbeddedClusterArtifacts.
@@ -958,6 +980,11 @@ func (in *HelmChartSpec) DeepCopyInto(out *HelmChartSpec) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.valuesKeys != nil {
+		in, out := &in.valuesKeys, &out.valuesKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.OptionalValues != nil {
 		in, out := &in.OptionalValues, &out.OptionalValues
 		*out = make([]*OptionalValue, len(*in))
@@ -1815,6 +1842,13 @@ func (in *MappedChartValue) DeepCopyInto(out *MappedChartValue) {
 			}
 		}
 	}
+	if in.keys != nil {
+		in, out := &in.keys, &out.keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.keyComments = in.keyComments
+	out.valueComments = in.valueComments
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MappedChartValue.
@@ -17,10 +17,12 @@ limitations under the License.
 package v1beta2
 
 import (
+	"bytes"
 	"encoding/json"
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kotskinds/apis/kots/internal/yamlnode"
 	"github.com/replicatedhq/kotskinds/multitype"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -34,10 +36,21 @@ type MappedChartValue struct {
 
 	strValue   string  `json:"-"`
 	boolValue  bool    `json:"-"`
+	intValue   int64   `json:"-"`
 	floatValue float64 `json:"-"`
+	// numberValue is a number as it was written in the source, such as 2.0 or 1e+21. It is
+	// set for every decoded "int" and "float", and is the only value of a "number", which is
+	// an integer too large for an int64 or a float too large for a float64.
+	numberValue json.Number `json:"-"`
 
 	children map[string]*MappedChartValue `json:"-"`
 	array    []*MappedChartValue          `json:"-"`
+
+	// keys, keyComments and valueComments are only recorded when decoding with
+	// go.yaml.in/yaml/v3, see UnmarshalYAML.
+	keys          []string          `json:"-"`
+	keyComments   yamlnode.Comments `json:"-"`
+	valueComments yamlnode.Comments `json:"-"`
 }
 
 func (m MappedChartValue) MarshalJSON() ([]byte, error) {
@@ -49,16 +62,29 @@ func (m MappedChartValue) MarshalJSON() ([]byte, error) {
 	return json.Marshal(val)
 }
 
+// getBuiltValue returns the value as plain go types. Decoded numbers are returned as the
+// json.Number they were written as, so that 2.0 stays 2.0 and large integers keep their
+// precision.
 func (m *MappedChartValue) getBuiltValue() (interface{}, error) {
+	if m.numberValue != "" {
+		return m.numberValue, nil
+	}
+
 	if m.valueType == "string" {
 		return m.strValue, nil
 	}
 	if m.valueType == "bool" {
 		return m.boolValue, nil
 	}
+	if m.valueType == "int" {
+		return m.intValue, nil
+	}
 	if m.valueType == "float" {
 		return m.floatValue, nil
 	}
+	if m.valueType == "number" {
+		return m.numberValue, nil
+	}
 	if m.valueType == "nil" {
 		return nil, nil
 	}
@@ -90,8 +116,13 @@ func (m *MappedChartValue) getBuiltValue() (interface{}, error) {
 }
 
 func (m *MappedChartValue) UnmarshalJSON(value []byte) error {
+	// numbers are decoded as json.Number so that they keep their precision and literal
+	// instead of going through float64
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+
 	var b interface{}
-	err := json.Unmarshal(value, &b)
+	err := decoder.Decode(&b)
 	if err != nil {
 		return err
 	}
@@ -113,9 +144,8 @@ func (m *MappedChartValue) UnmarshalJSON(value []byte) error {
 		return nil
 	}
 
-	if b, ok := b.(float64); ok {
-		m.floatValue = b
-		m.valueType = "float"
+	if b, ok := b.(json.Number); ok {
+		m.setNumber(b)
 		return nil
 	}
 
@@ -164,6 +194,25 @@ func (m *MappedChartValue) UnmarshalJSON(value []byte) error {
 	return errors.Errorf("unknown mapped chart value type: %T", b)
 }
 
+// setNumber sets the value to number, as an "int" when it fits an int64, a "float" when it
+// is not an integer and fits a float64, and a "number" otherwise
+func (m *MappedChartValue) setNumber(number json.Number) {
+	m.numberValue = number
+	if i, err := number.Int64(); err == nil {
+		m.intValue = i
+		m.valueType = "int"
+		return
+	}
+	if strings.ContainsAny(number.String(), ".eE") {
+		if f, err := number.Float64(); err == nil {
+			m.floatValue = f
+			m.valueType = "float"
+			return
+		}
+	}
+	m.valueType = "number"
+}
+
 type ChartIdentifier struct {
 	Name         string `json:"name"`
 	ChartVersion string `json:"chartVersion"`
@@ -496,6 +545,7 @@ type HelmChartSpec struct {
 	Exclude          multitype.BoolOrString      `json:"exclude,omitempty"`
 	Namespace        string                      `json:"namespace,omitempty"`
 	Values           map[string]MappedChartValue `json:"values,omitempty"`
+	valuesKeys       []string                    `json:"-"`
 	OptionalValues   []*OptionalValue            `json:"optionalValues,omitempty"`
 	Builder          map[string]MappedChartValue `json:"builder,omitempty"`
 	Weight           int64                       `json:"weight,omitempty"`
@@ -0,0 +1,133 @@
+package v1beta2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v3"
+)
+
+func Test_HelmChartSpecRenderValuesYAML(t *testing.T) {
+	data := `apiVersion: kots.io/v1beta2
+kind: HelmChart
+metadata:
+  name: test
+spec:
+  chart:
+    name: test
+    chartVersion: 1.0.0
+  values:
+    # the image to deploy
+    image:
+      repository: nginx
+      tag: "1.25" # pinned
+      pullPolicy: IfNotPresent
+    replicaCount: repl{{ ConfigOption "replicas" }}
+    enabled: true
+    ports:
+      - 8080
+      - name: metrics
+        port: 9090
+    zeta: ~
+    alpha: 1.5
+    whole: 2.0
+    big: 18446744073709551616
+`
+
+	helmChart := HelmChart{}
+	require.NoError(t, yaml.Unmarshal([]byte(data), &helmChart))
+
+	assert.Equal(t, "kots.io/v1beta2", helmChart.APIVersion)
+	assert.Equal(t, "test", helmChart.Name)
+	assert.Equal(t, "1.0.0", helmChart.Spec.Chart.ChartVersion)
+
+	rendered, err := helmChart.Spec.RenderValuesYAML()
+	require.NoError(t, err)
+
+	assert.Equal(t, `# the image to deploy
+image:
+  repository: nginx
+  tag: "1.25" # pinned
+  pullPolicy: IfNotPresent
+replicaCount: repl{{ ConfigOption "replicas" }}
+enabled: true
+ports:
+  - 8080
+  - name: metrics
+    port: 9090
+zeta: null
+alpha: 1.5
+whole: 2.0
+big: 18446744073709551616
+`, string(rendered))
+
+	// the values still build the same way as when decoded from json
+	values, err := helmChart.Spec.GetHelmValues(helmChart.Spec.Values)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"image": map[string]interface{}{
+			"repository": "nginx",
+			"tag":        "1.25",
+			"pullPolicy": "IfNotPresent",
+		},
+		"replicaCount": `repl{{ ConfigOption "replicas" }}`,
+		"enabled":      true,
+		"ports": []interface{}{
+			json.Number("8080"),
+			map[string]interface{}{"name": "metrics", "port": json.Number("9090")},
+		},
+		"zeta":  nil,
+		"alpha": json.Number("1.5"),
+		"whole": json.Number("2.0"),
+		"big":   json.Number("18446744073709551616"),
+	}, values)
+
+	// order survives a deep copy
+	copied, err := helmChart.DeepCopy().Spec.RenderValuesYAML()
+	require.NoError(t, err)
+	assert.Equal(t, string(rendered), string(copied))
+}
+
+func Test_HelmChartSpecRenderValuesYAML_WithoutRecordedOrder(t *testing.T) {
+	spec := HelmChartSpec{
+		Values: map[string]MappedChartValue{
+			"b": {valueType: "string", strValue: "true"},
+			"a": {
+				valueType: "children",
+				children: map[string]*MappedChartValue{
+					"y": {valueType: "bool", boolValue: false},
+					"x": {valueType: "float", floatValue: 2},
+				},
+			},
+		},
+	}
+
+	rendered, err := spec.RenderValuesYAML()
+	require.NoError(t, err)
+	assert.Equal(t, `a:
+  x: 2
+  "y": false
+b: "true"
+`, string(rendered))
+}
+
+func Test_MappedChartValueMarshalYAML(t *testing.T) {
+	data := `z: 1
+a:
+  - c: 3
+    b: 2
+`
+
+	value := MappedChartValue{}
+	require.NoError(t, yaml.Unmarshal([]byte(data), &value))
+
+	out, err := yaml.Marshal(value)
+	require.NoError(t, err)
+	assert.Equal(t, `z: 1
+a:
+    - c: 3
+      b: 2
+`, string(out))
+}
@@ -177,6 +177,11 @@ func (in *HelmChartSpec) DeepCopyInto(out *HelmChartSpec) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.valuesKeys != nil {
+		in, out := &in.valuesKeys, &out.valuesKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.OptionalValues != nil {
 		in, out := &in.OptionalValues, &out.OptionalValues
 		*out = make([]*OptionalValue, len(*in))
@@ -370,6 +375,13 @@ func (in *MappedChartValue) DeepCopyInto(out *MappedChartValue) {
 			}
 		}
 	}
+	if in.keys != nil {
+		in, out := &in.keys, &out.keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.keyComments = in.keyComments
+	out.valueComments = in.valueComments
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MappedChartValue.
@@ -0,0 +1,503 @@
+package airgap
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/pkg/imageref"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// AppArchiveName is the path of the archive of app manifests in a bundle
+	AppArchiveName = "app.tar.gz"
+	// ImagesDir holds an OCI image layout for each image saved in a bundle
+	ImagesDir = "images"
+	// EmbeddedClusterDir holds the embedded-cluster artifacts of a bundle
+	EmbeddedClusterDir = "embedded-cluster"
+	// DefaultRegistryDir is used for EmbeddedClusterRegistry.Dir when the spec does not set it
+	DefaultRegistryDir = EmbeddedClusterDir + "/registry"
+)
+
+// EmbeddedClusterArtifact is one of the single-file fields of EmbeddedClusterArtifacts
+type EmbeddedClusterArtifact string
+
+const (
+	EmbeddedClusterCharts      EmbeddedClusterArtifact = "charts"
+	EmbeddedClusterImagesAmd64 EmbeddedClusterArtifact = "imagesAmd64"
+	EmbeddedClusterBinaryAmd64 EmbeddedClusterArtifact = "binaryAmd64"
+	EmbeddedClusterMetadata    EmbeddedClusterArtifact = "metadata"
+	// EmbeddedClusterImages and EmbeddedClusterBinary are the per-architecture artifacts,
+	// see AddEmbeddedClusterArchArtifact
+	EmbeddedClusterImages EmbeddedClusterArtifact = "images"
+	EmbeddedClusterBinary EmbeddedClusterArtifact = "binary"
+)
+
+// Builder assembles an airgap bundle. Files are added with the Add methods and the bundle is
+// written with Write, which sets uncompressedSize, savedImages and the embedded-cluster
+// artifact paths in the manifest to match what was added. Builders are meant for producing
+// bundles in tests and tooling, and hold added manifests and artifacts in memory.
+type Builder struct {
+	spec    kotsv1beta1.AirgapSpec
+	entries []builderEntry
+	images  []string
+}
+
+type builderEntry struct {
+	name  string
+	size  int64
+	isDir bool
+	open  func() (io.ReadCloser, error)
+}
+
+// NewBuilder returns a Builder for a bundle described by spec
+func NewBuilder(spec kotsv1beta1.AirgapSpec) *Builder {
+	return &Builder{
+		spec: *spec.DeepCopy(),
+	}
+}
+
+// AddAppManifests adds the release manifests of the app, as app.tar.gz. files maps paths in
+// the release to their contents.
+func (b *Builder) AddAppManifests(files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for _, name := range names {
+		header := &tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     int64(len(files[name])),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return errors.Wrapf(err, "failed to write header for %s", name)
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return errors.Wrapf(err, "failed to write %s", name)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to close tar writer")
+	}
+	if err := gzw.Close(); err != nil {
+		return errors.Wrap(err, "failed to close gzip writer")
+	}
+
+	b.addFile(AppArchiveName, buf.Bytes())
+	return nil
+}
+
+// AddImage adds the OCI image layout in layout as the saved image for image, under
+// images/<repository>/<version>, and lists image in savedImages. The repository is the one
+// parsed by imageref.Parse, without the registry, and the version is the digest when image
+// is pinned to one, otherwise its tag, or latest.
+func (b *Builder) AddImage(image string, layout fs.FS) error {
+	if err := checkImageLayout(layout); err != nil {
+		return errors.Wrapf(err, "invalid image layout for %s", image)
+	}
+
+	ref, err := imageref.Parse(image)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse image")
+	}
+	dir := path.Join(ImagesDir, ref.Repository, imageVersion(ref))
+
+	err = fs.WalkDir(layout, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		b.addFS(path.Join(dir, name), info.Size(), layout, name)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to walk image layout for %s", image)
+	}
+
+	b.images = append(b.images, image)
+	return nil
+}
+
+// AddEmbeddedClusterArtifact adds data as the embedded-cluster artifact, under
+// embedded-cluster/<name>
+func (b *Builder) AddEmbeddedClusterArtifact(artifact EmbeddedClusterArtifact, name string, data []byte) error {
+	artifacts := b.embeddedClusterArtifacts()
+	p := path.Join(EmbeddedClusterDir, name)
+
+	switch artifact {
+	case EmbeddedClusterCharts:
+		artifacts.Charts = p
+	case EmbeddedClusterImagesAmd64:
+		artifacts.ImagesAmd64 = p
+	case EmbeddedClusterBinaryAmd64:
+		artifacts.BinaryAmd64 = p
+	case EmbeddedClusterMetadata:
+		artifacts.Metadata = p
+	default:
+		return errors.Errorf("unknown embedded cluster artifact %q", artifact)
+	}
+
+	b.addFile(p, data)
+	return nil
+}
+
+// AddEmbeddedClusterArchArtifact adds data as the images or binary embedded-cluster artifact
+// for arch, under embedded-cluster/<name>. The amd64 artifacts are also listed in
+// imagesAmd64 and binaryAmd64, for readers that don't know about architectures.
+func (b *Builder) AddEmbeddedClusterArchArtifact(arch string, artifact EmbeddedClusterArtifact, name string, data []byte) error {
+	artifacts := b.embeddedClusterArtifacts()
+	if artifacts.Architectures == nil {
+		artifacts.Architectures = map[string]kotsv1beta1.EmbeddedClusterArchArtifacts{}
+	}
+	archArtifacts := artifacts.Architectures[arch]
+	p := path.Join(EmbeddedClusterDir, name)
+	isLegacyArch := arch == kotsv1beta1.EmbeddedClusterLegacyArch
+
+	switch artifact {
+	case EmbeddedClusterImages:
+		archArtifacts.Images = p
+		if isLegacyArch {
+			artifacts.ImagesAmd64 = p
+		}
+	case EmbeddedClusterBinary:
+		archArtifacts.Binary = p
+		if isLegacyArch {
+			artifacts.BinaryAmd64 = p
+		}
+	default:
+		return errors.Errorf("%q is not a per-architecture embedded cluster artifact", artifact)
+	}
+
+	artifacts.Architectures[arch] = archArtifacts
+	b.addFile(p, data)
+	return nil
+}
+
+// AddAdditionalArtifact adds data as the additional embedded-cluster artifact key, under
+// embedded-cluster/<name>
+func (b *Builder) AddAdditionalArtifact(key string, name string, data []byte) {
+	artifacts := b.embeddedClusterArtifacts()
+	if artifacts.AdditionalArtifacts == nil {
+		artifacts.AdditionalArtifacts = map[string]string{}
+	}
+
+	p := path.Join(EmbeddedClusterDir, name)
+	artifacts.AdditionalArtifacts[key] = p
+	b.addFile(p, data)
+}
+
+// AddRegistryImage pushes the OCI image layout in layout into the embedded-cluster registry
+// directory as image, using the storage layout of a filesystem backed docker registry, and
+// lists image in the registry's savedImages. Every blob referenced from the layout's
+// index.json is stored, following image indexes to their manifests.
+func (b *Builder) AddRegistryImage(image string, layout fs.FS) error {
+	if err := checkImageLayout(layout); err != nil {
+		return errors.Wrapf(err, "invalid image layout for %s", image)
+	}
+	ref, err := imageref.Parse(image)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse image")
+	}
+
+	artifacts := b.embeddedClusterArtifacts()
+	if artifacts.Registry.Dir == "" {
+		artifacts.Registry.Dir = DefaultRegistryDir
+	}
+	root := path.Join(artifacts.Registry.Dir, "docker", "registry", "v2")
+
+	index := ociIndex{}
+	if err := readLayoutJSON(layout, "index.json", &index); err != nil {
+		return errors.Wrap(err, "failed to read index.json")
+	}
+	if len(index.Manifests) == 0 {
+		return errors.Errorf("index.json for %s has no manifests", image)
+	}
+
+	repositoryDir := path.Join(root, "repositories", ref.Repository)
+
+	stored := map[string]bool{}
+	storeBlob := func(descriptor ociDescriptor) error {
+		algorithm, hex, ok := strings.Cut(descriptor.Digest, ":")
+		if !ok || len(hex) < 2 {
+			return errors.Errorf("invalid digest %q", descriptor.Digest)
+		}
+		if stored[descriptor.Digest] {
+			return nil
+		}
+		stored[descriptor.Digest] = true
+
+		blobName := path.Join("blobs", algorithm, hex)
+		info, err := fs.Stat(layout, blobName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find blob %s", descriptor.Digest)
+		}
+		b.addFS(path.Join(root, "blobs", algorithm, hex[:2], hex, "data"), info.Size(), layout, blobName)
+		return nil
+	}
+
+	var storeManifest func(descriptor ociDescriptor) error
+	storeManifest = func(descriptor ociDescriptor) error {
+		if err := storeBlob(descriptor); err != nil {
+			return err
+		}
+		algorithm, hex, _ := strings.Cut(descriptor.Digest, ":")
+		b.addFile(path.Join(repositoryDir, "_manifests", "revisions", algorithm, hex, "link"), []byte(descriptor.Digest))
+
+		manifest := ociManifest{}
+		if err := readLayoutJSON(layout, path.Join("blobs", algorithm, hex), &manifest); err != nil {
+			return errors.Wrapf(err, "failed to read manifest %s", descriptor.Digest)
+		}
+
+		for _, child := range manifest.Manifests {
+			if err := storeManifest(child); err != nil {
+				return err
+			}
+		}
+
+		layers := manifest.Layers
+		if manifest.Config != nil {
+			layers = append([]ociDescriptor{*manifest.Config}, layers...)
+		}
+		for _, layer := range layers {
+			if err := storeBlob(layer); err != nil {
+				return err
+			}
+			layerAlgorithm, layerHex, _ := strings.Cut(layer.Digest, ":")
+			b.addFile(path.Join(repositoryDir, "_layers", layerAlgorithm, layerHex, "link"), []byte(layer.Digest))
+		}
+		return nil
+	}
+
+	top := index.Manifests[0]
+	if err := storeManifest(top); err != nil {
+		return errors.Wrapf(err, "failed to store %s", image)
+	}
+
+	algorithm, hex, _ := strings.Cut(top.Digest, ":")
+	tag := ref.Tag
+	if tag == "" && !ref.IsDigestPinned() {
+		tag = imageref.DefaultTag
+	}
+	if tag != "" {
+		tagDir := path.Join(repositoryDir, "_manifests", "tags", tag)
+		b.addFile(path.Join(tagDir, "current", "link"), []byte(top.Digest))
+		b.addFile(path.Join(tagDir, "index", algorithm, hex, "link"), []byte(top.Digest))
+	}
+
+	artifacts.Registry.SavedImages = append(artifacts.Registry.SavedImages, image)
+	return nil
+}
+
+// Spec returns the manifest as it will be written by Write
+func (b *Builder) Spec() kotsv1beta1.AirgapSpec {
+	spec := *b.spec.DeepCopy()
+
+	if len(b.images) > 0 {
+		spec.SavedImages = append([]string{}, b.images...)
+	}
+
+	spec.UncompressedSize = 0
+	for _, entry := range b.entries {
+		spec.UncompressedSize += entry.size
+	}
+
+	return spec
+}
+
+// WriteFile writes the bundle to filename. See Write.
+func (b *Builder) WriteFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.Wrap(err, "failed to create bundle")
+	}
+	defer f.Close()
+
+	if err := b.Write(f); err != nil {
+		return err
+	}
+	return errors.Wrap(f.Close(), "failed to close bundle")
+}
+
+// Write writes the bundle to w as a gzip compressed tarball, with airgap.yaml first
+func (b *Builder) Write(w io.Writer) error {
+	airgap := kotsv1beta1.Airgap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "kots.io/v1beta1",
+			Kind:       "Airgap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: b.spec.AppSlug,
+		},
+		Spec: b.Spec(),
+	}
+	manifest, err := yaml.Marshal(airgap)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal airgap manifest")
+	}
+
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+
+	entries := []builderEntry{{
+		name: ManifestName,
+		size: int64(len(manifest)),
+		open: bytesOpener(manifest),
+	}}
+	// the registry directory is always written, even when no images were pushed to it
+	if airgap.Spec.EmbeddedClusterArtifacts != nil && airgap.Spec.EmbeddedClusterArtifacts.Registry.Dir != "" {
+		entries = append(entries, builderEntry{name: cleanPath(airgap.Spec.EmbeddedClusterArtifacts.Registry.Dir), isDir: true})
+	}
+	entries = append(entries, b.entries...)
+
+	for _, entry := range entries {
+		if err := writeEntry(tw, entry); err != nil {
+			return errors.Wrapf(err, "failed to write %s", entry.name)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to close tar writer")
+	}
+	if err := gzw.Close(); err != nil {
+		return errors.Wrap(err, "failed to close gzip writer")
+	}
+	return nil
+}
+
+func writeEntry(tw *tar.Writer, entry builderEntry) error {
+	if entry.isDir {
+		return tw.WriteHeader(&tar.Header{
+			Name:     entry.name + "/",
+			Typeflag: tar.TypeDir,
+			Mode:     0755,
+		})
+	}
+
+	header := &tar.Header{
+		Name:     entry.name,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     entry.size,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	r, err := entry.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(tw, r)
+	return err
+}
+
+func (b *Builder) embeddedClusterArtifacts() *kotsv1beta1.EmbeddedClusterArtifacts {
+	if b.spec.EmbeddedClusterArtifacts == nil {
+		b.spec.EmbeddedClusterArtifacts = &kotsv1beta1.EmbeddedClusterArtifacts{}
+	}
+	return b.spec.EmbeddedClusterArtifacts
+}
+
+// addFile adds data at name, replacing any file already added there
+func (b *Builder) addFile(name string, data []byte) {
+	b.addEntry(builderEntry{name: cleanPath(name), size: int64(len(data)), open: bytesOpener(data)})
+}
+
+func (b *Builder) addFS(name string, size int64, fsys fs.FS, fsName string) {
+	b.addEntry(builderEntry{
+		name: cleanPath(name),
+		size: size,
+		open: func() (io.ReadCloser, error) {
+			return fsys.Open(fsName)
+		},
+	})
+}
+
+func (b *Builder) addEntry(entry builderEntry) {
+	for i := range b.entries {
+		if b.entries[i].name == entry.name {
+			b.entries[i] = entry
+			return
+		}
+	}
+	b.entries = append(b.entries, entry)
+}
+
+func bytesOpener(data []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+// ociDescriptor, ociIndex and ociManifest hold the parts of the OCI image spec needed to
+// find the blobs of an image
+type ociDescriptor struct {
+	MediaType string `json:"mediaType,omitempty"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	Config    *ociDescriptor  `json:"config,omitempty"`
+	Layers    []ociDescriptor `json:"layers,omitempty"`
+	Manifests []ociDescriptor `json:"manifests,omitempty"`
+}
+
+func checkImageLayout(layout fs.FS) error {
+	for _, name := range []string{"oci-layout", "index.json"} {
+		if _, err := fs.Stat(layout, name); err != nil {
+			return errors.Errorf("%s not found", name)
+		}
+	}
+	return nil
+}
+
+func readLayoutJSON(layout fs.FS, name string, v interface{}) error {
+	data, err := fs.ReadFile(layout, name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// imageVersion returns the directory name of the saved image for ref: its digest, with the
+// colon replaced, when it is pinned to one, otherwise its tag, or latest
+func imageVersion(ref imageref.Reference) string {
+	if ref.IsDigestPinned() {
+		return strings.ReplaceAll(ref.Digest, ":", "-")
+	}
+	if ref.Tag != "" {
+		return ref.Tag
+	}
+	return imageref.DefaultTag
+}
@@ -0,0 +1,148 @@
+package airgap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImageLayout returns a single image OCI layout, and the digests of its manifest and layer
+func testImageLayout(t *testing.T) (fstest.MapFS, string, string) {
+	t.Helper()
+
+	layout := fstest.MapFS{
+		"oci-layout": &fstest.MapFile{Data: []byte(`{"imageLayoutVersion":"1.0.0"}`)},
+	}
+	addBlob := func(data []byte) string {
+		sum := sha256.Sum256(data)
+		layout["blobs/sha256/"+hex.EncodeToString(sum[:])] = &fstest.MapFile{Data: data}
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	config := addBlob([]byte(`{"architecture":"amd64","os":"linux"}`))
+	layer := addBlob([]byte("layer contents"))
+	manifest, err := json.Marshal(ociManifest{
+		Config: &ociDescriptor{MediaType: "application/vnd.oci.image.config.v1+json", Digest: config},
+		Layers: []ociDescriptor{{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: layer}},
+	})
+	require.NoError(t, err)
+	manifestDigest := addBlob(manifest)
+
+	index, err := json.Marshal(ociIndex{
+		Manifests: []ociDescriptor{{MediaType: "application/vnd.oci.image.manifest.v1+json", Digest: manifestDigest}},
+	})
+	require.NoError(t, err)
+	layout["index.json"] = &fstest.MapFile{Data: index}
+
+	return layout, manifestDigest, layer
+}
+
+func TestBuilder(t *testing.T) {
+	layout, manifestDigest, layerDigest := testImageLayout(t)
+
+	builder := NewBuilder(kotsv1beta1.AirgapSpec{
+		AppSlug:   "my-app",
+		ChannelID: "stable-id",
+		AirgapReleaseMeta: kotsv1beta1.AirgapReleaseMeta{
+			VersionLabel: "1.0.0",
+			UpdateCursor: "1",
+		},
+		SavedImages: []string{"ignored:1.0"},
+	})
+
+	require.NoError(t, builder.AddAppManifests(map[string][]byte{
+		"deployment.yaml": []byte("apiVersion: apps/v1\nkind: Deployment\n"),
+	}))
+	require.NoError(t, builder.AddImage("registry.example.com/my-app/api:1.0.0", layout))
+	require.NoError(t, builder.AddEmbeddedClusterArtifact(EmbeddedClusterBinaryAmd64, "my-app", []byte("binary")))
+	require.NoError(t, builder.AddEmbeddedClusterArtifact(EmbeddedClusterCharts, "charts.tar.gz", []byte("charts")))
+	require.Error(t, builder.AddEmbeddedClusterArtifact("imagesArm64", "images-arm64.tar", []byte("images")))
+	require.NoError(t, builder.AddEmbeddedClusterArchArtifact("arm64", EmbeddedClusterBinary, "arm64/my-app", []byte("arm64 binary")))
+	require.NoError(t, builder.AddEmbeddedClusterArchArtifact("arm64", EmbeddedClusterImages, "images-arm64.tar", []byte("arm64 images")))
+	require.NoError(t, builder.AddEmbeddedClusterArchArtifact("amd64", EmbeddedClusterImages, "images-amd64.tar", []byte("amd64 images")))
+	require.Error(t, builder.AddEmbeddedClusterArchArtifact("arm64", EmbeddedClusterCharts, "charts.tar.gz", []byte("charts")))
+	builder.AddAdditionalArtifact("kots", "kots.tar.gz", []byte("kots"))
+	require.NoError(t, builder.AddRegistryImage("docker.io/library/nginx:1.25", layout))
+
+	var buf bytes.Buffer
+	require.NoError(t, builder.Write(&buf))
+
+	bundle, err := Read(&buf)
+	require.NoError(t, err)
+	require.NoError(t, bundle.Verify())
+
+	spec := bundle.Airgap.Spec
+	assert.Equal(t, "my-app", bundle.Airgap.Name)
+	assert.Equal(t, "1.0.0", spec.VersionLabel)
+	assert.Equal(t, []string{"registry.example.com/my-app/api:1.0.0"}, spec.SavedImages)
+	assert.Equal(t, bundle.Size, spec.UncompressedSize)
+	assert.Equal(t, builder.Spec().UncompressedSize, spec.UncompressedSize)
+
+	require.NotNil(t, spec.EmbeddedClusterArtifacts)
+	assert.Equal(t, "embedded-cluster/my-app", spec.EmbeddedClusterArtifacts.BinaryAmd64)
+	assert.Equal(t, "embedded-cluster/charts.tar.gz", spec.EmbeddedClusterArtifacts.Charts)
+	assert.Equal(t, []string{"amd64", "arm64"}, spec.EmbeddedClusterArtifacts.Arches())
+	assert.Equal(t, "embedded-cluster/my-app", spec.EmbeddedClusterArtifacts.BinaryFor("amd64"))
+	assert.Equal(t, "embedded-cluster/images-amd64.tar", spec.EmbeddedClusterArtifacts.ImagesAmd64)
+	assert.Equal(t, "embedded-cluster/images-amd64.tar", spec.EmbeddedClusterArtifacts.ImagesFor("amd64"))
+	assert.Equal(t, "embedded-cluster/arm64/my-app", spec.EmbeddedClusterArtifacts.BinaryFor("arm64"))
+	assert.Equal(t, "embedded-cluster/images-arm64.tar", spec.EmbeddedClusterArtifacts.ImagesFor("arm64"))
+	assert.Equal(t, map[string]string{"kots": "embedded-cluster/kots.tar.gz"}, spec.EmbeddedClusterArtifacts.AdditionalArtifacts)
+	assert.Equal(t, DefaultRegistryDir, spec.EmbeddedClusterArtifacts.Registry.Dir)
+	assert.Equal(t, []string{"docker.io/library/nginx:1.25"}, spec.EmbeddedClusterArtifacts.Registry.SavedImages)
+
+	assert.True(t, bundle.HasFile("app.tar.gz"))
+	assert.True(t, bundle.HasFile("images/my-app/api/1.0.0/index.json"))
+	assert.True(t, bundle.HasFile("images/my-app/api/1.0.0/oci-layout"))
+
+	registry := "embedded-cluster/registry/docker/registry/v2/"
+	manifestHex := manifestDigest[len("sha256:"):]
+	layerHex := layerDigest[len("sha256:"):]
+	assert.True(t, bundle.HasFile(registry+"blobs/sha256/"+manifestHex[:2]+"/"+manifestHex+"/data"))
+	assert.True(t, bundle.HasFile(registry+"blobs/sha256/"+layerHex[:2]+"/"+layerHex+"/data"))
+	assert.True(t, bundle.HasFile(registry+"repositories/library/nginx/_manifests/tags/1.25/current/link"))
+	assert.True(t, bundle.HasFile(registry+"repositories/library/nginx/_manifests/revisions/sha256/"+manifestHex+"/link"))
+	assert.True(t, bundle.HasFile(registry+"repositories/library/nginx/_layers/sha256/"+layerHex+"/link"))
+}
+
+func TestBuilderInvalidLayout(t *testing.T) {
+	builder := NewBuilder(kotsv1beta1.AirgapSpec{})
+	require.Error(t, builder.AddImage("nginx", fstest.MapFS{}))
+	require.Error(t, builder.AddRegistryImage("nginx", fstest.MapFS{"oci-layout": &fstest.MapFile{}}))
+}
+
+func TestBuilderImageLayout(t *testing.T) {
+	layout, manifestDigest, _ := testImageLayout(t)
+	manifestHex := manifestDigest[len("sha256:"):]
+
+	builder := NewBuilder(kotsv1beta1.AirgapSpec{})
+	require.NoError(t, builder.AddImage("localhost:5000/my-app/api:1.0", layout))
+	require.NoError(t, builder.AddImage("registry.example.com:5000/my-app/web", layout))
+	require.NoError(t, builder.AddImage("my-app/worker@"+manifestDigest, layout))
+	require.NoError(t, builder.AddImage("nginx:1.25@"+manifestDigest, layout))
+	require.NoError(t, builder.AddRegistryImage("registry.example.com:5000/my-app/api:1.0", layout))
+	require.NoError(t, builder.AddRegistryImage("registry.example.com:5000/my-app/worker@"+manifestDigest, layout))
+	require.Error(t, builder.AddImage("Invalid Image", layout))
+
+	var buf bytes.Buffer
+	require.NoError(t, builder.Write(&buf))
+	bundle, err := Read(&buf)
+	require.NoError(t, err)
+
+	assert.True(t, bundle.HasFile("images/my-app/api/1.0/index.json"))
+	assert.True(t, bundle.HasFile("images/my-app/web/latest/index.json"))
+	assert.True(t, bundle.HasFile("images/my-app/worker/sha256-"+manifestHex+"/index.json"))
+	assert.True(t, bundle.HasFile("images/library/nginx/sha256-"+manifestHex+"/index.json"))
+
+	registry := "embedded-cluster/registry/docker/registry/v2/repositories/"
+	assert.True(t, bundle.HasFile(registry+"my-app/api/_manifests/tags/1.0/current/link"))
+	assert.True(t, bundle.HasFile(registry+"my-app/worker/_manifests/revisions/sha256/"+manifestHex+"/link"))
+	assert.False(t, bundle.HasDir(registry+"my-app/worker/_manifests/tags"))
+}
@@ -0,0 +1,125 @@
+package airgap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+)
+
+// ManifestNotFoundError is returned when a bundle has no airgap.yaml
+type ManifestNotFoundError struct {
+	Name string
+}
+
+func (e *ManifestNotFoundError) Error() string {
+	return fmt.Sprintf("%s not found in bundle", e.Name)
+}
+
+func IsManifestNotFoundError(err error) bool {
+	var mnfe *ManifestNotFoundError
+	return errors.As(err, &mnfe)
+}
+
+// ManifestTooLargeError is returned when the airgap.yaml in a bundle is larger than the
+// size that is read into memory
+type ManifestTooLargeError struct {
+	Name  string
+	Size  int64
+	Limit int64
+}
+
+func (e *ManifestTooLargeError) Error() string {
+	return fmt.Sprintf("%s is too large: %d bytes, the limit is %d", e.Name, e.Size, e.Limit)
+}
+
+func IsManifestTooLargeError(err error) bool {
+	var mtle *ManifestTooLargeError
+	return errors.As(err, &mtle)
+}
+
+// MissingArtifactError is a path listed in the Airgap manifest that is not in the bundle
+type MissingArtifactError struct {
+	// Field is the manifest field that lists the path, such as
+	// "embeddedClusterArtifacts.binaryAmd64"
+	Field string
+	Path  string
+}
+
+func (e *MissingArtifactError) Error() string {
+	return fmt.Sprintf("%s: %s not found in bundle", e.Field, e.Path)
+}
+
+func IsMissingArtifactError(err error) bool {
+	var mae *MissingArtifactError
+	return errors.As(err, &mae)
+}
+
+// SizeMismatchError is returned when the size of the files in a bundle does not match the
+// uncompressedSize in its manifest
+type SizeMismatchError struct {
+	Expected int64
+	Actual   int64
+}
+
+func (e *SizeMismatchError) Error() string {
+	return fmt.Sprintf("uncompressed size is %d bytes, manifest says %d", e.Actual, e.Expected)
+}
+
+func IsSizeMismatchError(err error) bool {
+	var sme *SizeMismatchError
+	return errors.As(err, &sme)
+}
+
+// VerificationError holds every problem found when verifying a bundle
+type VerificationError struct {
+	Problems []error
+}
+
+func (e *VerificationError) Error() string {
+	messages := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		messages = append(messages, problem.Error())
+	}
+	return fmt.Sprintf("bundle verification failed: %s", strings.Join(messages, "; "))
+}
+
+func (e *VerificationError) Unwrap() []error {
+	return e.Problems
+}
+
+// MissingRequiredReleaseError is returned when an upgrade must go through a required release
+// that is not in the bundles available
+type MissingRequiredReleaseError struct {
+	Release    kotsv1beta1.AirgapReleaseMeta
+	RequiredBy kotsv1beta1.AirgapReleaseMeta
+}
+
+func (e *MissingRequiredReleaseError) Error() string {
+	return fmt.Sprintf("required release %s (cursor %s) is missing, required by %s", e.Release.VersionLabel, e.Release.UpdateCursor, releaseName(e.RequiredBy))
+}
+
+func IsMissingRequiredReleaseError(err error) bool {
+	var mrre *MissingRequiredReleaseError
+	return errors.As(err, &mrre)
+}
+
+// InstallationMismatchError is returned when an Installation does not match the release in
+// an airgap bundle
+type InstallationMismatchError struct {
+	Mismatches []Mismatch
+}
+
+func (e *InstallationMismatchError) Error() string {
+	messages := make([]string, 0, len(e.Mismatches))
+	for _, mismatch := range e.Mismatches {
+		messages = append(messages, mismatch.String())
+	}
+	return fmt.Sprintf("installation does not match airgap bundle: %s", strings.Join(messages, "; "))
+}
+
+func IsInstallationMismatchError(err error) bool {
+	var ime *InstallationMismatchError
+	return errors.As(err, &ime)
+}
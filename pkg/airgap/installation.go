@@ -0,0 +1,150 @@
+package airgap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/pkg/licensewrapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// InstallationFromAirgap returns the Installation of the release in airgap for license. The
+// channel comes from the bundle, or from the license when the bundle has none, and the
+// channel name is looked up in the license channels when the bundle does not set it. The
+// installation is named after the app. An *AirgapAppSlugMismatchError is returned when the
+// bundle is for a different app than the license.
+func InstallationFromAirgap(airgap *kotsv1beta1.Airgap, license licensewrapper.LicenseWrapper) (*kotsv1beta1.Installation, error) {
+	appSlug := license.GetAppSlug()
+	if airgap.Spec.AppSlug != "" {
+		if appSlug != "" && airgap.Spec.AppSlug != appSlug {
+			return nil, &kotsv1beta1.AirgapAppSlugMismatchError{AirgapAppSlug: airgap.Spec.AppSlug, LicenseAppSlug: appSlug}
+		}
+		appSlug = airgap.Spec.AppSlug
+	}
+
+	channelID, channelName := airgap.Spec.ChannelID, airgap.Spec.ChannelName
+	if channelID == "" {
+		channelID, channelName = license.GetChannelID(), license.GetChannelName()
+	}
+	if channelName == "" {
+		for _, channel := range license.GetChannels() {
+			if channel.ChannelID == channelID {
+				channelName = channel.ChannelName
+				break
+			}
+		}
+	}
+
+	installation := &kotsv1beta1.Installation{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: kotsv1beta1.SchemeGroupVersion.String(),
+			Kind:       "Installation",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appSlug,
+		},
+		Spec: kotsv1beta1.InstallationSpec{
+			UpdateCursor: airgap.Spec.UpdateCursor,
+			ChannelID:    channelID,
+			ChannelName:  channelName,
+			VersionLabel: airgap.Spec.VersionLabel,
+			IsRequired:   airgap.Spec.IsRequired,
+			ReleaseNotes: airgap.Spec.ReleaseNotes,
+		},
+	}
+	if airgap.Spec.ReplicatedChartNames != nil {
+		installation.Spec.ReplicatedChartNames = append([]string{}, airgap.Spec.ReplicatedChartNames...)
+	}
+	if airgap.Spec.EmbeddedClusterArtifacts != nil {
+		installation.Spec.EmbeddedClusterArtifacts = airgap.Spec.EmbeddedClusterArtifacts.DeepCopy()
+	}
+
+	return installation, nil
+}
+
+// Mismatch is a field repeated in an Installation and an Airgap that differs between them
+type Mismatch struct {
+	// Field is the json name of the field, such as "channelID", or
+	// "embeddedClusterArchitectures" for the architectures of embeddedClusterArtifacts
+	Field        string
+	Installation string
+	Airgap       string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: installation has %q, airgap has %q", m.Field, m.Installation, m.Airgap)
+}
+
+// FindMismatches returns the differences between installation and a candidate airgap
+// bundle that an upgrade to the bundle should not have, in the order below. Most of the
+// fields they share describe a single release, such as the version label, release notes,
+// update cursor and embedded cluster artifact paths and images, and change with every
+// upgrade, so only these are checked:
+//
+//   - channelID, when the bundle sets one
+//   - channelName, when the bundle sets one and the channel IDs are the same, as a renamed
+//     channel is reported through its ID
+//   - replicatedChartNames, when the bundle sets them and does not include every chart of the
+//     installation. Charts that are added are not reported.
+//   - embeddedClusterArchitectures, when the bundle has embedded cluster artifacts and is
+//     missing an architecture the installation has artifacts for
+//
+// Fields the bundle does not set are not reported, as older bundles do not include them.
+func FindMismatches(installation *kotsv1beta1.Installation, airgap *kotsv1beta1.Airgap) []Mismatch {
+	mismatches := []Mismatch{}
+	add := func(field, installed, bundled string) {
+		mismatches = append(mismatches, Mismatch{Field: field, Installation: installed, Airgap: bundled})
+	}
+
+	if airgap.Spec.ChannelID != "" && installation.Spec.ChannelID != airgap.Spec.ChannelID {
+		add("channelID", installation.Spec.ChannelID, airgap.Spec.ChannelID)
+	} else if airgap.Spec.ChannelName != "" && installation.Spec.ChannelName != airgap.Spec.ChannelName {
+		add("channelName", installation.Spec.ChannelName, airgap.Spec.ChannelName)
+	}
+
+	if len(airgap.Spec.ReplicatedChartNames) > 0 && len(missing(installation.Spec.ReplicatedChartNames, airgap.Spec.ReplicatedChartNames)) > 0 {
+		add("replicatedChartNames", sortedList(installation.Spec.ReplicatedChartNames), sortedList(airgap.Spec.ReplicatedChartNames))
+	}
+
+	if airgap.Spec.EmbeddedClusterArtifacts != nil {
+		installed, bundled := installation.Spec.EmbeddedClusterArtifacts.Arches(), airgap.Spec.EmbeddedClusterArtifacts.Arches()
+		if len(missing(installed, bundled)) > 0 {
+			add("embeddedClusterArchitectures", sortedList(installed), sortedList(bundled))
+		}
+	}
+
+	return mismatches
+}
+
+// CheckInstallation returns an *InstallationMismatchError when installation does not match
+// the release in airgap, see FindMismatches
+func CheckInstallation(installation *kotsv1beta1.Installation, airgap *kotsv1beta1.Airgap) error {
+	mismatches := FindMismatches(installation, airgap)
+	if len(mismatches) > 0 {
+		return &InstallationMismatchError{Mismatches: mismatches}
+	}
+	return nil
+}
+
+func sortedList(values []string) string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// missing returns the values in installed that are not in bundled
+func missing(installed, bundled []string) []string {
+	present := map[string]bool{}
+	for _, value := range bundled {
+		present[value] = true
+	}
+	result := []string{}
+	for _, value := range installed {
+		if !present[value] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,170 @@
+package airgap
+
+import (
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/pkg/licensewrapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testInstallationAirgap() *kotsv1beta1.Airgap {
+	return &kotsv1beta1.Airgap{
+		Spec: kotsv1beta1.AirgapSpec{
+			AirgapReleaseMeta: kotsv1beta1.AirgapReleaseMeta{
+				VersionLabel: "1.2.0",
+				ReleaseNotes: "fixes",
+				UpdateCursor: "12",
+			},
+			ChannelID:            "beta-id",
+			AppSlug:              "my-app",
+			IsRequired:           true,
+			ReplicatedChartNames: []string{"web", "db"},
+			EmbeddedClusterArtifacts: &kotsv1beta1.EmbeddedClusterArtifacts{
+				Charts:      "embedded-cluster/charts.tar.gz",
+				BinaryAmd64: "embedded-cluster/my-app",
+			},
+		},
+	}
+}
+
+func testInstallationLicense() licensewrapper.LicenseWrapper {
+	return licensewrapper.LicenseWrapper{
+		V1: &kotsv1beta1.License{
+			Spec: kotsv1beta1.LicenseSpec{
+				AppSlug:     "my-app",
+				ChannelID:   "stable-id",
+				ChannelName: "Stable",
+				Channels: []kotsv1beta1.Channel{
+					{ChannelID: "stable-id", ChannelName: "Stable"},
+					{ChannelID: "beta-id", ChannelName: "Beta"},
+				},
+			},
+		},
+	}
+}
+
+func TestInstallationFromAirgap(t *testing.T) {
+	airgap := testInstallationAirgap()
+
+	installation, err := InstallationFromAirgap(airgap, testInstallationLicense())
+	require.NoError(t, err)
+	assert.Equal(t, "kots.io/v1beta1", installation.APIVersion)
+	assert.Equal(t, "Installation", installation.Kind)
+	assert.Equal(t, "my-app", installation.Name)
+	assert.Equal(t, kotsv1beta1.InstallationSpec{
+		UpdateCursor:             "12",
+		ChannelID:                "beta-id",
+		ChannelName:              "Beta",
+		VersionLabel:             "1.2.0",
+		IsRequired:               true,
+		ReleaseNotes:             "fixes",
+		ReplicatedChartNames:     []string{"web", "db"},
+		EmbeddedClusterArtifacts: airgap.Spec.EmbeddedClusterArtifacts,
+	}, installation.Spec)
+	assert.NotSame(t, airgap.Spec.EmbeddedClusterArtifacts, installation.Spec.EmbeddedClusterArtifacts)
+
+	assert.Empty(t, FindMismatches(installation, airgap))
+	assert.NoError(t, CheckInstallation(installation, airgap))
+}
+
+func TestInstallationFromAirgapLicenseChannel(t *testing.T) {
+	airgap := testInstallationAirgap()
+	airgap.Spec.ChannelID = ""
+
+	installation, err := InstallationFromAirgap(airgap, testInstallationLicense())
+	require.NoError(t, err)
+	assert.Equal(t, "stable-id", installation.Spec.ChannelID)
+	assert.Equal(t, "Stable", installation.Spec.ChannelName)
+}
+
+func TestInstallationFromAirgapAppSlugMismatch(t *testing.T) {
+	airgap := testInstallationAirgap()
+	airgap.Spec.AppSlug = "other-app"
+
+	_, err := InstallationFromAirgap(airgap, testInstallationLicense())
+	var mismatchErr *kotsv1beta1.AirgapAppSlugMismatchError
+	require.ErrorAs(t, err, &mismatchErr)
+	assert.Equal(t, "other-app", mismatchErr.AirgapAppSlug)
+}
+
+func TestFindMismatches(t *testing.T) {
+	installation, err := InstallationFromAirgap(testInstallationAirgap(), testInstallationLicense())
+	require.NoError(t, err)
+
+	t.Run("older bundle", func(t *testing.T) {
+		airgap := testInstallationAirgap()
+		airgap.Spec.ChannelID = ""
+		airgap.Spec.ReplicatedChartNames = nil
+		airgap.Spec.EmbeddedClusterArtifacts = nil
+		assert.Empty(t, FindMismatches(installation, airgap))
+	})
+
+	t.Run("different channel", func(t *testing.T) {
+		airgap := testInstallationAirgap()
+		airgap.Spec.ChannelID = "stable-id"
+		airgap.Spec.ChannelName = "Stable"
+		assert.Equal(t, []Mismatch{
+			{Field: "channelID", Installation: "beta-id", Airgap: "stable-id"},
+		}, FindMismatches(installation, airgap))
+	})
+
+	t.Run("renamed channel", func(t *testing.T) {
+		airgap := testInstallationAirgap()
+		airgap.Spec.ChannelName = "Preview"
+		assert.Equal(t, []Mismatch{
+			{Field: "channelName", Installation: "Beta", Airgap: "Preview"},
+		}, FindMismatches(installation, airgap))
+	})
+
+	t.Run("removed chart and architecture", func(t *testing.T) {
+		airgap := testInstallationAirgap()
+		airgap.Spec.ReplicatedChartNames = []string{"web", "cache"}
+		airgap.Spec.EmbeddedClusterArtifacts = &kotsv1beta1.EmbeddedClusterArtifacts{
+			Architectures: map[string]kotsv1beta1.EmbeddedClusterArchArtifacts{
+				"arm64": {Binary: "embedded-cluster/arm64/my-app"},
+			},
+		}
+		mismatches := FindMismatches(installation, airgap)
+		assert.Equal(t, []Mismatch{
+			{Field: "replicatedChartNames", Installation: "db,web", Airgap: "cache,web"},
+			{Field: "embeddedClusterArchitectures", Installation: "amd64", Airgap: "arm64"},
+		}, mismatches)
+
+		err := CheckInstallation(installation, airgap)
+		require.Error(t, err)
+		assert.True(t, IsInstallationMismatchError(err))
+		assert.Contains(t, err.Error(), `replicatedChartNames: installation has "db,web", airgap has "cache,web"`)
+	})
+}
+
+func TestFindMismatchesUpgrade(t *testing.T) {
+	installation, err := InstallationFromAirgap(testInstallationAirgap(), testInstallationLicense())
+	require.NoError(t, err)
+
+	// a new release on the same channel, with new artifacts, images and an added chart
+	upgrade := testInstallationAirgap()
+	upgrade.Spec.VersionLabel = "1.3.0"
+	upgrade.Spec.ReleaseNotes = "new features"
+	upgrade.Spec.UpdateCursor = "13"
+	upgrade.Spec.ChannelName = "Beta"
+	upgrade.Spec.IsRequired = false
+	upgrade.Spec.ReplicatedChartNames = []string{"db", "web", "cache"}
+	upgrade.Spec.EmbeddedClusterArtifacts = &kotsv1beta1.EmbeddedClusterArtifacts{
+		Charts:      "embedded-cluster/charts-1.3.0.tar.gz",
+		BinaryAmd64: "embedded-cluster/my-app-1.3.0",
+		ImagesAmd64: "embedded-cluster/images-amd64-1.3.0.tar",
+		Metadata:    "embedded-cluster/version-metadata-1.3.0.json",
+		Architectures: map[string]kotsv1beta1.EmbeddedClusterArchArtifacts{
+			"arm64": {Binary: "embedded-cluster/arm64/my-app-1.3.0"},
+		},
+		Registry: kotsv1beta1.EmbeddedClusterRegistry{
+			Dir:         "embedded-cluster/registry",
+			SavedImages: []string{"my-app/api:1.3.0", "my-app/web:1.3.0"},
+		},
+	}
+
+	assert.Empty(t, FindMismatches(installation, upgrade))
+	assert.NoError(t, CheckInstallation(installation, upgrade))
+}
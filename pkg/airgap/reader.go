@@ -0,0 +1,181 @@
+// Package airgap reads, writes and plans upgrades with .airgap bundles.
+//
+// A bundle is a tarball, usually gzip compressed, holding an airgap.yaml manifest next to
+// the app archive, images and embedded-cluster artifacts. The reader makes a single pass
+// over the tarball, recording the name and size of every entry and decoding airgap.yaml,
+// without extracting anything to disk. Builder writes bundles in the same layout.
+package airgap
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	kotsscheme "github.com/replicatedhq/kotskinds/client/kotsclientset/scheme"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func init() {
+	kotsscheme.AddToScheme(scheme.Scheme)
+}
+
+// ManifestName is the path of the Airgap manifest in a bundle
+const ManifestName = "airgap.yaml"
+
+// maxManifestSize is the largest airgap.yaml that is read into memory
+const maxManifestSize = 32 * 1024 * 1024
+
+// Entry is a file or directory in a bundle
+type Entry struct {
+	Name  string
+	Size  int64
+	IsDir bool
+}
+
+// Bundle is the contents of a .airgap bundle, as read by Read
+type Bundle struct {
+	Airgap *kotsv1beta1.Airgap
+	// Entries are the files and directories in the bundle, keyed by their cleaned path
+	Entries map[string]Entry
+	// Size is the sum of the sizes of the regular files in the bundle, other than
+	// airgap.yaml itself, which is what the manifest's uncompressedSize describes
+	Size int64
+}
+
+// OpenFile reads the bundle at filename. See Read.
+func OpenFile(filename string) (*Bundle, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open bundle")
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Read reads a bundle from r, which may be a plain or gzip compressed tarball. Only
+// airgap.yaml is kept in memory, other entries are only recorded. A bundle without
+// airgap.yaml returns a *ManifestNotFoundError.
+func Read(r io.Reader) (*Bundle, error) {
+	tr, err := newTarReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &Bundle{
+		Entries: map[string]Entry{},
+	}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read tar header")
+		}
+
+		name := cleanPath(header.Name)
+		if name == "" {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			bundle.Entries[name] = Entry{Name: name, IsDir: true}
+
+		case tar.TypeReg:
+			bundle.Entries[name] = Entry{Name: name, Size: header.Size}
+
+			if name != ManifestName {
+				bundle.Size += header.Size
+			} else {
+				if header.Size > maxManifestSize {
+					return nil, &ManifestTooLargeError{Name: ManifestName, Size: header.Size, Limit: maxManifestSize}
+				}
+				airgap, err := decodeManifest(tr)
+				if err != nil {
+					return nil, err
+				}
+				bundle.Airgap = airgap
+			}
+		}
+	}
+
+	if bundle.Airgap == nil {
+		return nil, &ManifestNotFoundError{Name: ManifestName}
+	}
+
+	return bundle, nil
+}
+
+// HasFile returns true if the bundle contains a regular file at p
+func (b *Bundle) HasFile(p string) bool {
+	entry, ok := b.Entries[cleanPath(p)]
+	return ok && !entry.IsDir
+}
+
+// HasDir returns true if the bundle contains a directory at p, either as its own entry or
+// as the parent of another entry
+func (b *Bundle) HasDir(p string) bool {
+	dir := cleanPath(p)
+	if dir == "" {
+		return false
+	}
+	if entry, ok := b.Entries[dir]; ok {
+		return entry.IsDir
+	}
+	for name := range b.Entries {
+		if strings.HasPrefix(name, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func newTarReader(r io.Reader) (*tar.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "failed to read bundle")
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create gzip reader")
+		}
+		return tar.NewReader(gzr), nil
+	}
+	return tar.NewReader(br), nil
+}
+
+func decodeManifest(r io.Reader) (*kotsv1beta1.Airgap, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", ManifestName)
+	}
+
+	decode := scheme.Codecs.UniversalDeserializer().Decode
+	obj, _, err := decode(data, nil, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s", ManifestName)
+	}
+
+	airgap, ok := obj.(*kotsv1beta1.Airgap)
+	if !ok {
+		return nil, errors.Errorf("unexpected object type %T in %s", obj, ManifestName)
+	}
+	return airgap, nil
+}
+
+// cleanPath returns p relative to the root of the bundle, without a leading "./" or "/"
+func cleanPath(p string) string {
+	cleaned := path.Clean("/" + p)
+	return strings.TrimPrefix(cleaned, "/")
+}
@@ -0,0 +1,168 @@
+package airgap
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tarEntry struct {
+	name    string
+	content string
+	dir     bool
+}
+
+func buildTar(t *testing.T, compress bool, entries []tarEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var gzw *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if compress {
+		gzw = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gzw)
+	}
+
+	for _, entry := range entries {
+		if entry.dir {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: entry.name, Typeflag: tar.TypeDir, Mode: 0755}))
+			continue
+		}
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: entry.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(entry.content))}))
+		_, err := tw.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	if gzw != nil {
+		require.NoError(t, gzw.Close())
+	}
+	return buf.Bytes()
+}
+
+const testManifest = `apiVersion: kots.io/v1beta1
+kind: Airgap
+metadata:
+  name: my-app
+spec:
+  appSlug: my-app
+  channelID: channel-1
+  versionLabel: 1.0.0
+  updateCursor: "3"
+  uncompressedSize: 37
+  embeddedClusterArtifacts:
+    charts: embedded-cluster/charts.tar.gz
+    imagesAmd64: embedded-cluster/images-amd64.tar
+    binaryAmd64: embedded-cluster/my-app
+    metadata: embedded-cluster/version-metadata.json
+    registry:
+      dir: embedded-cluster/registry
+    additionalArtifacts:
+      kots: embedded-cluster/kots.tar.gz
+    architectures:
+      arm64:
+        binary: embedded-cluster/arm64/my-app
+`
+
+func testEntries() []tarEntry {
+	return []tarEntry{
+		{name: "./airgap.yaml", content: testManifest},
+		{name: "./app.tar.gz", content: "app"},
+		{name: "./embedded-cluster/", dir: true},
+		{name: "./embedded-cluster/charts.tar.gz", content: "charts"},
+		{name: "./embedded-cluster/images-amd64.tar", content: "images"},
+		{name: "./embedded-cluster/my-app", content: "bin"},
+		{name: "./embedded-cluster/version-metadata.json", content: "{}"},
+		{name: "./embedded-cluster/registry/docker/registry/v2/blobs/sha256/ab", content: "blob"},
+		{name: "./embedded-cluster/kots.tar.gz", content: "kots"},
+		{name: "./embedded-cluster/arm64/my-app", content: "arm64 bin"},
+	}
+}
+
+func TestRead(t *testing.T) {
+	for _, compress := range []bool{true, false} {
+		bundle, err := Read(bytes.NewReader(buildTar(t, compress, testEntries())))
+		require.NoError(t, err)
+
+		assert.Equal(t, "my-app", bundle.Airgap.Spec.AppSlug)
+		assert.Equal(t, "1.0.0", bundle.Airgap.Spec.VersionLabel)
+		assert.Equal(t, int64(37), bundle.Size)
+		assert.True(t, bundle.HasFile("app.tar.gz"))
+		assert.True(t, bundle.HasFile("./embedded-cluster/my-app"))
+		assert.False(t, bundle.HasFile("embedded-cluster"))
+		assert.True(t, bundle.HasDir("embedded-cluster"))
+		assert.True(t, bundle.HasDir("embedded-cluster/registry"))
+		assert.False(t, bundle.HasDir("embedded-cluster/my-app"))
+
+		assert.NoError(t, bundle.Verify())
+	}
+}
+
+func TestReadMissingManifest(t *testing.T) {
+	_, err := Read(bytes.NewReader(buildTar(t, true, []tarEntry{{name: "app.tar.gz", content: "app"}})))
+	require.Error(t, err)
+	assert.True(t, IsManifestNotFoundError(err))
+}
+
+func TestReadManifestTooLarge(t *testing.T) {
+	manifest := testManifest + "#" + strings.Repeat(" ", maxManifestSize)
+	_, err := Read(bytes.NewReader(buildTar(t, false, []tarEntry{{name: "airgap.yaml", content: manifest}})))
+	require.Error(t, err)
+	assert.True(t, IsManifestTooLargeError(err))
+}
+
+func TestReadWrongKind(t *testing.T) {
+	manifest := "apiVersion: kots.io/v1beta1\nkind: Application\nmetadata:\n  name: my-app\n"
+	_, err := Read(bytes.NewReader(buildTar(t, true, []tarEntry{{name: "airgap.yaml", content: manifest}})))
+	require.Error(t, err)
+	assert.False(t, IsManifestNotFoundError(err))
+}
+
+func TestVerify(t *testing.T) {
+	entries := []tarEntry{}
+	for _, entry := range testEntries() {
+		switch entry.name {
+		case "./embedded-cluster/my-app", "./embedded-cluster/kots.tar.gz", "./embedded-cluster/arm64/my-app":
+			continue
+		}
+		if entry.name == "./embedded-cluster/registry/docker/registry/v2/blobs/sha256/ab" {
+			entry.name = "./embedded-cluster/other/ab"
+		}
+		entries = append(entries, entry)
+	}
+
+	bundle, err := Read(bytes.NewReader(buildTar(t, true, entries)))
+	require.NoError(t, err)
+
+	err = bundle.Verify()
+	require.Error(t, err)
+	assert.True(t, IsMissingArtifactError(err))
+	assert.True(t, IsSizeMismatchError(err))
+
+	var verificationErr *VerificationError
+	require.True(t, errors.As(err, &verificationErr))
+
+	missing := []MissingArtifactError{}
+	for _, problem := range verificationErr.Problems {
+		if mae, ok := problem.(*MissingArtifactError); ok {
+			missing = append(missing, *mae)
+		}
+	}
+	assert.Equal(t, []MissingArtifactError{
+		{Field: "embeddedClusterArtifacts.binaryAmd64", Path: "embedded-cluster/my-app"},
+		{Field: "embeddedClusterArtifacts.additionalArtifacts[kots]", Path: "embedded-cluster/kots.tar.gz"},
+		{Field: "embeddedClusterArtifacts.architectures[arm64].binary", Path: "embedded-cluster/arm64/my-app"},
+		{Field: "embeddedClusterArtifacts.registry.dir", Path: "embedded-cluster/registry"},
+	}, missing)
+
+	var sizeErr *SizeMismatchError
+	require.True(t, errors.As(err, &sizeErr))
+	assert.Equal(t, int64(37), sizeErr.Expected)
+	assert.Equal(t, int64(21), sizeErr.Actual)
+}
@@ -0,0 +1,108 @@
+package airgap
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/pkg/licensewrapper"
+	"github.com/replicatedhq/kotskinds/pkg/version"
+)
+
+// PlanUpgrade returns the airgap bundles to apply, in order, to upgrade installation to the
+// newest release in bundles. Releases that are not newer than the installed one are ignored.
+// Releases between the installed one and the newest are skipped unless they are required,
+// either because their bundle sets isRequired or because a later bundle lists them in
+// requiredReleases. A required release with no bundle returns a *MissingRequiredReleaseError.
+// An installation that is already up to date returns an empty plan.
+//
+// Releases are ordered with a version.Comparator for the license channel of the installation.
+func PlanUpgrade(installation *kotsv1beta1.Installation, bundles []*kotsv1beta1.Airgap, license licensewrapper.LicenseWrapper) ([]*kotsv1beta1.Airgap, error) {
+	comparator := version.NewComparator(license, installation.Spec.ChannelID)
+	installed := version.FromInstallation(installation)
+
+	candidates := []*kotsv1beta1.Airgap{}
+	for _, bundle := range bundles {
+		newer, err := comparator.IsNewer(version.FromReleaseMeta(bundle.Spec.AirgapReleaseMeta), installed)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to compare release %s", releaseName(bundle.Spec.AirgapReleaseMeta))
+		}
+		if !newer || findRelease(candidates, bundle.Spec.AirgapReleaseMeta) != nil {
+			continue
+		}
+		candidates = append(candidates, bundle)
+	}
+	if len(candidates) == 0 {
+		return []*kotsv1beta1.Airgap{}, nil
+	}
+
+	var sortErr error
+	sort.SliceStable(candidates, func(i, j int) bool {
+		cmp, err := comparator.Compare(version.FromReleaseMeta(candidates[i].Spec.AirgapReleaseMeta), version.FromReleaseMeta(candidates[j].Spec.AirgapReleaseMeta))
+		if err != nil && sortErr == nil {
+			sortErr = err
+		}
+		return cmp < 0
+	})
+	if sortErr != nil {
+		return nil, errors.Wrap(sortErr, "failed to order releases")
+	}
+
+	target := candidates[len(candidates)-1]
+
+	plan := []*kotsv1beta1.Airgap{}
+	for _, bundle := range candidates[:len(candidates)-1] {
+		if bundle.Spec.IsRequired {
+			plan = append(plan, bundle)
+		}
+	}
+
+	for _, bundle := range candidates {
+		for _, required := range bundle.Spec.RequiredReleases {
+			newer, err := comparator.IsNewer(version.FromReleaseMeta(required), installed)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to compare required release %s", releaseName(required))
+			}
+			if !newer {
+				continue
+			}
+
+			requiredBundle := findRelease(candidates, required)
+			if requiredBundle == nil {
+				return nil, &MissingRequiredReleaseError{Release: required, RequiredBy: bundle.Spec.AirgapReleaseMeta}
+			}
+			if requiredBundle != target && findRelease(plan, required) == nil {
+				plan = append(plan, requiredBundle)
+			}
+		}
+	}
+
+	sort.SliceStable(plan, func(i, j int) bool {
+		cmp, _ := comparator.Compare(version.FromReleaseMeta(plan[i].Spec.AirgapReleaseMeta), version.FromReleaseMeta(plan[j].Spec.AirgapReleaseMeta))
+		return cmp < 0
+	})
+
+	return append(plan, target), nil
+}
+
+// findRelease returns the bundle in bundles for release, matched by update cursor, or by
+// version label when release has no cursor
+func findRelease(bundles []*kotsv1beta1.Airgap, release kotsv1beta1.AirgapReleaseMeta) *kotsv1beta1.Airgap {
+	for _, bundle := range bundles {
+		if release.UpdateCursor != "" {
+			if bundle.Spec.UpdateCursor == release.UpdateCursor {
+				return bundle
+			}
+		} else if bundle.Spec.VersionLabel == release.VersionLabel {
+			return bundle
+		}
+	}
+	return nil
+}
+
+func releaseName(release kotsv1beta1.AirgapReleaseMeta) string {
+	if release.VersionLabel != "" {
+		return release.VersionLabel
+	}
+	return release.UpdateCursor
+}
@@ -0,0 +1,164 @@
+package airgap
+
+import (
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/pkg/licensewrapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBundle(versionLabel string, cursor string, isRequired bool, requiredReleases ...kotsv1beta1.AirgapReleaseMeta) *kotsv1beta1.Airgap {
+	return &kotsv1beta1.Airgap{
+		Spec: kotsv1beta1.AirgapSpec{
+			AirgapReleaseMeta: kotsv1beta1.AirgapReleaseMeta{
+				VersionLabel: versionLabel,
+				UpdateCursor: cursor,
+			},
+			IsRequired:       isRequired,
+			RequiredReleases: requiredReleases,
+		},
+	}
+}
+
+func testInstallation(versionLabel string, cursor string) *kotsv1beta1.Installation {
+	return &kotsv1beta1.Installation{
+		Spec: kotsv1beta1.InstallationSpec{
+			VersionLabel: versionLabel,
+			UpdateCursor: cursor,
+			ChannelID:    "stable-id",
+		},
+	}
+}
+
+func planLabels(plan []*kotsv1beta1.Airgap) []string {
+	labels := []string{}
+	for _, bundle := range plan {
+		labels = append(labels, bundle.Spec.VersionLabel)
+	}
+	return labels
+}
+
+func TestPlanUpgrade(t *testing.T) {
+	cursorLicense := licensewrapper.LicenseWrapper{V1: &kotsv1beta1.License{}}
+	semverLicense := licensewrapper.LicenseWrapper{V1: &kotsv1beta1.License{
+		Spec: kotsv1beta1.LicenseSpec{IsSemverRequired: true},
+	}}
+	channelSemverLicense := licensewrapper.LicenseWrapper{V1: &kotsv1beta1.License{
+		Spec: kotsv1beta1.LicenseSpec{
+			Channels: []kotsv1beta1.Channel{{ChannelID: "stable-id", IsSemverRequired: true}},
+		},
+	}}
+
+	tests := []struct {
+		name         string
+		installation *kotsv1beta1.Installation
+		bundles      []*kotsv1beta1.Airgap
+		license      licensewrapper.LicenseWrapper
+		want         []string
+	}{
+		{
+			name:         "skips releases that are not required",
+			installation: testInstallation("1.0.0", "1"),
+			bundles: []*kotsv1beta1.Airgap{
+				testBundle("1.2.0", "3", false),
+				testBundle("1.1.0", "2", false),
+			},
+			license: cursorLicense,
+			want:    []string{"1.2.0"},
+		},
+		{
+			name:         "required bundles are applied in order",
+			installation: testInstallation("1.0.0", "1"),
+			bundles: []*kotsv1beta1.Airgap{
+				testBundle("1.3.0", "4", false),
+				testBundle("1.2.0", "3", true),
+				testBundle("1.1.0", "2", true),
+			},
+			license: cursorLicense,
+			want:    []string{"1.1.0", "1.2.0", "1.3.0"},
+		},
+		{
+			name:         "required releases listed by a later bundle",
+			installation: testInstallation("1.0.0", "1"),
+			bundles: []*kotsv1beta1.Airgap{
+				testBundle("1.3.0", "4", false,
+					kotsv1beta1.AirgapReleaseMeta{VersionLabel: "1.1.0", UpdateCursor: "2"},
+					kotsv1beta1.AirgapReleaseMeta{VersionLabel: "0.9.0", UpdateCursor: "0"},
+				),
+				testBundle("1.2.0", "3", false),
+				testBundle("1.1.0", "2", false),
+			},
+			license: cursorLicense,
+			want:    []string{"1.1.0", "1.3.0"},
+		},
+		{
+			name:         "older and installed releases are ignored",
+			installation: testInstallation("1.1.0", "2"),
+			bundles: []*kotsv1beta1.Airgap{
+				testBundle("1.0.0", "1", true),
+				testBundle("1.1.0", "2", true),
+			},
+			license: cursorLicense,
+			want:    []string{},
+		},
+		{
+			name:         "semver ordering when the license requires it",
+			installation: testInstallation("1.0.0", "5"),
+			bundles: []*kotsv1beta1.Airgap{
+				testBundle("1.10.0", "6", false),
+				testBundle("1.2.0", "7", true),
+				testBundle("1.2.0-beta.1", "8", true),
+			},
+			license: semverLicense,
+			want:    []string{"1.2.0-beta.1", "1.2.0", "1.10.0"},
+		},
+		{
+			name:         "semver ordering when the channel requires it",
+			installation: testInstallation("1.0.0", "5"),
+			bundles: []*kotsv1beta1.Airgap{
+				testBundle("1.10.0", "6", false),
+				testBundle("1.2.0", "7", true),
+			},
+			license: channelSemverLicense,
+			want:    []string{"1.2.0", "1.10.0"},
+		},
+		{
+			name:         "cursor ordering ignores version labels",
+			installation: testInstallation("1.0.0", "5"),
+			bundles: []*kotsv1beta1.Airgap{
+				testBundle("1.10.0", "6", false),
+				testBundle("1.2.0", "7", true),
+			},
+			license: cursorLicense,
+			want:    []string{"1.2.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := PlanUpgrade(tt.installation, tt.bundles, tt.license)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, planLabels(plan))
+		})
+	}
+}
+
+func TestPlanUpgradeMissingRequiredRelease(t *testing.T) {
+	bundles := []*kotsv1beta1.Airgap{
+		testBundle("1.3.0", "4", false,
+			kotsv1beta1.AirgapReleaseMeta{VersionLabel: "1.2.0", UpdateCursor: "3"},
+		),
+	}
+
+	_, err := PlanUpgrade(testInstallation("1.0.0", "1"), bundles, licensewrapper.LicenseWrapper{V1: &kotsv1beta1.License{}})
+	require.Error(t, err)
+	require.True(t, IsMissingRequiredReleaseError(err))
+	assert.Equal(t, "3", err.(*MissingRequiredReleaseError).Release.UpdateCursor)
+}
+
+func TestPlanUpgradeInvalidCursor(t *testing.T) {
+	_, err := PlanUpgrade(testInstallation("1.0.0", "1"), []*kotsv1beta1.Airgap{testBundle("1.1.0", "abc", false)}, licensewrapper.LicenseWrapper{V1: &kotsv1beta1.License{}})
+	require.Error(t, err)
+}
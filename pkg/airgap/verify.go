@@ -0,0 +1,71 @@
+package airgap
+
+import (
+	"fmt"
+	"sort"
+)
+
+// artifactPath is a path listed in the manifest, and the field that lists it
+type artifactPath struct {
+	field string
+	path  string
+}
+
+// Verify checks the bundle against its manifest. Every path in EmbeddedClusterArtifacts, for
+// every architecture, must be in the bundle, with Registry.Dir as a directory, and when the
+// manifest sets uncompressedSize it must match the size of the files in the bundle. It
+// returns a *VerificationError listing every *MissingArtifactError and *SizeMismatchError
+// found, or nil.
+func (b *Bundle) Verify() error {
+	problems := []error{}
+
+	if artifacts := b.Airgap.Spec.EmbeddedClusterArtifacts; artifacts != nil {
+		files := []artifactPath{
+			{"embeddedClusterArtifacts.charts", artifacts.Charts},
+			{"embeddedClusterArtifacts.imagesAmd64", artifacts.ImagesAmd64},
+			{"embeddedClusterArtifacts.binaryAmd64", artifacts.BinaryAmd64},
+			{"embeddedClusterArtifacts.metadata", artifacts.Metadata},
+		}
+
+		names := make([]string, 0, len(artifacts.AdditionalArtifacts))
+		for name := range artifacts.AdditionalArtifacts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			files = append(files, artifactPath{fmt.Sprintf("embeddedClusterArtifacts.additionalArtifacts[%s]", name), artifacts.AdditionalArtifacts[name]})
+		}
+
+		arches := make([]string, 0, len(artifacts.Architectures))
+		for arch := range artifacts.Architectures {
+			arches = append(arches, arch)
+		}
+		sort.Strings(arches)
+		for _, arch := range arches {
+			field := fmt.Sprintf("embeddedClusterArtifacts.architectures[%s]", arch)
+			files = append(files,
+				artifactPath{field + ".images", artifacts.Architectures[arch].Images},
+				artifactPath{field + ".binary", artifacts.Architectures[arch].Binary},
+			)
+		}
+
+		for _, file := range files {
+			if file.path != "" && !b.HasFile(file.path) {
+				problems = append(problems, &MissingArtifactError{Field: file.field, Path: file.path})
+			}
+		}
+
+		if dir := artifacts.Registry.Dir; dir != "" && !b.HasDir(dir) {
+			problems = append(problems, &MissingArtifactError{Field: "embeddedClusterArtifacts.registry.dir", Path: dir})
+		}
+	}
+
+	if expected := b.Airgap.Spec.UncompressedSize; expected != 0 && expected != b.Size {
+		problems = append(problems, &SizeMismatchError{Expected: expected, Actual: b.Size})
+	}
+
+	if len(problems) > 0 {
+		return &VerificationError{Problems: problems}
+	}
+	return nil
+}
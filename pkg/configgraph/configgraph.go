@@ -0,0 +1,310 @@
+// Package configgraph builds the dependency graph between KOTS config items created by
+// their `when` expressions, such as `repl{{ ConfigOptionEquals "database" "external" }}`.
+//
+// An item depends on every item referenced by its own `when` and by the `when` of its
+// group, since a change to any of them can change whether the item is shown.
+package configgraph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/pkg/repltemplate"
+)
+
+// UnknownReference is a `when` expression that references an item that is not in the config
+type UnknownReference struct {
+	Group string
+	// Item is empty when the reference is in the group's `when`
+	Item      string
+	Reference string
+}
+
+// Graph is the dependency graph between the items of a config
+type Graph struct {
+	// items are the item names in the order they are declared
+	items   []string
+	groupOf map[string]string
+	// groups are the group names and titles in the order they are declared
+	groups      []kotsv1beta1.ConfigGroup
+	dependsOn   map[string][]string
+	dependents  map[string][]string
+	unknownRefs []UnknownReference
+}
+
+// Build parses the `when` of every group and item in spec and returns the resulting graph.
+// An error is returned if any `when` can't be parsed. When an item name is declared more
+// than once, only the first declaration is used.
+func Build(spec kotsv1beta1.ConfigSpec) (*Graph, error) {
+	g := &Graph{
+		items:       []string{},
+		groupOf:     map[string]string{},
+		groups:      []kotsv1beta1.ConfigGroup{},
+		dependsOn:   map[string][]string{},
+		dependents:  map[string][]string{},
+		unknownRefs: []UnknownReference{},
+	}
+
+	for _, group := range spec.Groups {
+		g.groups = append(g.groups, kotsv1beta1.ConfigGroup{Name: group.Name, Title: group.Title})
+		for _, item := range group.Items {
+			if _, ok := g.groupOf[item.Name]; ok {
+				continue
+			}
+			g.items = append(g.items, item.Name)
+			g.groupOf[item.Name] = group.Name
+		}
+	}
+
+	for _, group := range spec.Groups {
+		groupRefs, err := references(string(group.When))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse when of group %s", group.Name)
+		}
+		for _, ref := range groupRefs {
+			if _, ok := g.groupOf[ref]; !ok {
+				g.unknownRefs = append(g.unknownRefs, UnknownReference{Group: group.Name, Reference: ref})
+			}
+		}
+
+		for _, item := range group.Items {
+			if g.groupOf[item.Name] != group.Name {
+				continue
+			}
+
+			itemRefs, err := references(string(item.When))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse when of item %s", item.Name)
+			}
+
+			deps := map[string]bool{}
+			for _, ref := range itemRefs {
+				if _, ok := g.groupOf[ref]; !ok {
+					g.unknownRefs = append(g.unknownRefs, UnknownReference{Group: group.Name, Item: item.Name, Reference: ref})
+					continue
+				}
+				deps[ref] = true
+			}
+			for _, ref := range groupRefs {
+				if _, ok := g.groupOf[ref]; ok {
+					deps[ref] = true
+				}
+			}
+
+			for _, dep := range g.ordered(deps) {
+				g.dependsOn[item.Name] = append(g.dependsOn[item.Name], dep)
+				g.dependents[dep] = append(g.dependents[dep], item.Name)
+			}
+		}
+	}
+
+	return g, nil
+}
+
+func references(when string) ([]string, error) {
+	if !repltemplate.IsTemplate(when) {
+		return nil, nil
+	}
+	analysis, err := repltemplate.Analyze(when)
+	if err != nil {
+		return nil, err
+	}
+	return analysis.ConfigItems, nil
+}
+
+// Items returns the names of all items in the order they are declared
+func (g *Graph) Items() []string {
+	return append([]string{}, g.items...)
+}
+
+// DependsOn returns the items whose values the visibility of item directly depends on
+func (g *Graph) DependsOn(item string) []string {
+	return append([]string{}, g.dependsOn[item]...)
+}
+
+// Dependents returns the items whose visibility directly depends on the value of item
+func (g *Graph) Dependents(item string) []string {
+	return append([]string{}, g.dependents[item]...)
+}
+
+// AffectedBy returns every item whose visibility can change when the value of item changes,
+// either directly or because an item it depends on is shown or hidden. Items are returned
+// in the order they are declared, and item itself is only included when it is in a cycle.
+func (g *Graph) AffectedBy(item string) []string {
+	affected := map[string]bool{}
+	queue := []string{item}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range g.dependents[current] {
+			if !affected[dependent] {
+				affected[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	return g.ordered(affected)
+}
+
+// UnknownReferences returns the references to items that are not in the config
+func (g *Graph) UnknownReferences() []UnknownReference {
+	return append([]UnknownReference{}, g.unknownRefs...)
+}
+
+// Cycles returns each set of items whose visibility depends on each other, including items
+// that depend on themselves. Items in each cycle, and the cycles themselves, are in the
+// order the items are declared.
+func (g *Graph) Cycles() [][]string {
+	// Tarjan's strongly connected components
+	index := 0
+	indexes := map[string]int{}
+	lowlinks := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	cycles := [][]string{}
+
+	var connect func(item string)
+	connect = func(item string) {
+		indexes[item] = index
+		lowlinks[item] = index
+		index++
+		stack = append(stack, item)
+		onStack[item] = true
+
+		for _, dep := range g.dependsOn[item] {
+			if _, visited := indexes[dep]; !visited {
+				connect(dep)
+				if lowlinks[dep] < lowlinks[item] {
+					lowlinks[item] = lowlinks[dep]
+				}
+			} else if onStack[dep] && indexes[dep] < lowlinks[item] {
+				lowlinks[item] = indexes[dep]
+			}
+		}
+
+		if lowlinks[item] != indexes[item] {
+			return
+		}
+
+		component := map[string]bool{}
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component[last] = true
+			if last == item {
+				break
+			}
+		}
+
+		if len(component) > 1 || g.dependsOnItself(item) {
+			cycles = append(cycles, g.ordered(component))
+		}
+	}
+
+	for _, item := range g.items {
+		if _, visited := indexes[item]; !visited {
+			connect(item)
+		}
+	}
+
+	position := g.positions()
+	sort.Slice(cycles, func(i, j int) bool {
+		return position[cycles[i][0]] < position[cycles[j][0]]
+	})
+	return cycles
+}
+
+func (g *Graph) dependsOnItself(item string) bool {
+	for _, dep := range g.dependsOn[item] {
+		if dep == item {
+			return true
+		}
+	}
+	return false
+}
+
+// DOT returns the graph in Graphviz DOT format. Items are clustered by group, edges point
+// from an item to the items whose visibility depends on it, and unknown references are
+// drawn as dashed red nodes.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph config {\n")
+	b.WriteString("  rankdir=LR;\n")
+
+	for i, group := range g.groups {
+		label := group.Title
+		if label == "" {
+			label = group.Name
+		}
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote(label))
+		for _, item := range g.items {
+			if g.groupOf[item] == group.Name {
+				fmt.Fprintf(&b, "    %s;\n", dotQuote(item))
+			}
+		}
+		b.WriteString("  }\n")
+	}
+
+	unknown := map[string]bool{}
+	for _, ref := range g.unknownRefs {
+		if !unknown[ref.Reference] {
+			unknown[ref.Reference] = true
+			fmt.Fprintf(&b, "  %s [style=dashed, color=red];\n", dotQuote(ref.Reference))
+		}
+	}
+
+	for _, item := range g.items {
+		for _, dep := range g.dependsOn[item] {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(dep), dotQuote(item))
+		}
+	}
+	for _, ref := range g.unknownRefs {
+		targets := []string{ref.Item}
+		if ref.Item == "" {
+			targets = []string{}
+			for _, item := range g.items {
+				if g.groupOf[item] == ref.Group {
+					targets = append(targets, item)
+				}
+			}
+		}
+		for _, target := range targets {
+			fmt.Fprintf(&b, "  %s -> %s [style=dashed, color=red];\n", dotQuote(ref.Reference), dotQuote(target))
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote returns s as a DOT double-quoted string. DOT only escapes quotes and
+// backslashes, other characters, including non-ASCII ones, are written as they are.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// ordered returns the items in set in the order they are declared
+func (g *Graph) ordered(set map[string]bool) []string {
+	result := []string{}
+	for _, item := range g.items {
+		if set[item] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func (g *Graph) positions() map[string]int {
+	position := map[string]int{}
+	for i, item := range g.items {
+		position[item] = i
+	}
+	return position
+}
@@ -0,0 +1,129 @@
+package configgraph
+
+import (
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSpec = kotsv1beta1.ConfigSpec{
+	Groups: []kotsv1beta1.ConfigGroup{
+		{
+			Name:  "database",
+			Title: "Database",
+			Items: []kotsv1beta1.ConfigItem{
+				{Name: "db_type", Type: "select_one"},
+				{Name: "db_host", Type: "text", When: `repl{{ ConfigOptionEquals "db_type" "external" }}`},
+				{Name: "db_tls", Type: "bool", When: `repl{{ ConfigOptionEquals "db_type" "external" }}`},
+				{Name: "db_ca", Type: "file", When: `{{repl and (ConfigOptionEquals "db_type" "external") (ConfigOptionEquals "db_tls" "1") }}`},
+			},
+		},
+		{
+			Name: "advanced",
+			When: `repl{{ ConfigOptionEquals "show_advanced" "1" }}`,
+			Items: []kotsv1beta1.ConfigItem{
+				{Name: "debug", Type: "bool"},
+				{Name: "a", Type: "text", When: `repl{{ ConfigOptionNotEquals "b" "" }}`},
+				{Name: "b", Type: "text", When: `repl{{ ConfigOptionNotEquals "a" "" }}`},
+				{Name: "self", Type: "text", When: `repl{{ ConfigOptionEquals "self" "1" }}`},
+				{Name: "legacy", Type: "text", When: "false"},
+			},
+		},
+	},
+}
+
+func TestBuild(t *testing.T) {
+	g, err := Build(testSpec)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"db_type", "db_host", "db_tls", "db_ca", "debug", "a", "b", "self", "legacy"}, g.Items())
+	assert.Equal(t, []string{"db_type", "db_tls"}, g.DependsOn("db_ca"))
+	assert.Equal(t, []string{"db_host", "db_tls", "db_ca"}, g.Dependents("db_type"))
+	assert.Equal(t, []string{}, g.DependsOn("debug"))
+
+	assert.Equal(t, []string{"db_host", "db_tls", "db_ca"}, g.AffectedBy("db_type"))
+	assert.Equal(t, []string{"db_ca"}, g.AffectedBy("db_tls"))
+	assert.Equal(t, []string{"a", "b"}, g.AffectedBy("a"))
+	assert.Equal(t, []string{}, g.AffectedBy("debug"))
+
+	assert.Equal(t, [][]string{{"a", "b"}, {"self"}}, g.Cycles())
+
+	assert.Equal(t, []UnknownReference{
+		{Group: "advanced", Reference: "show_advanced"},
+	}, g.UnknownReferences())
+}
+
+func TestBuild_ParseError(t *testing.T) {
+	_, err := Build(kotsv1beta1.ConfigSpec{
+		Groups: []kotsv1beta1.ConfigGroup{
+			{Name: "g", Items: []kotsv1beta1.ConfigItem{{Name: "a", When: `repl{{ ConfigOptionEquals "b" `}}},
+		},
+	})
+	require.Error(t, err)
+}
+
+func TestDOT(t *testing.T) {
+	g, err := Build(kotsv1beta1.ConfigSpec{
+		Groups: []kotsv1beta1.ConfigGroup{
+			{
+				Name:  "database",
+				Title: "Database",
+				Items: []kotsv1beta1.ConfigItem{
+					{Name: "db_type"},
+					{Name: "db_host", When: `repl{{ ConfigOptionEquals "db_type" "external" }}`},
+				},
+			},
+			{
+				Name: "advanced",
+				When: `repl{{ ConfigOptionEquals "missing" "1" }}`,
+				Items: []kotsv1beta1.ConfigItem{
+					{Name: "debug"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, `digraph config {
+  rankdir=LR;
+  subgraph cluster_0 {
+    label="Database";
+    "db_type";
+    "db_host";
+  }
+  subgraph cluster_1 {
+    label="advanced";
+    "debug";
+  }
+  "missing" [style=dashed, color=red];
+  "db_type" -> "db_host";
+  "missing" -> "debug" [style=dashed, color=red];
+}
+`, g.DOT())
+}
+
+func TestDOT_Quoting(t *testing.T) {
+	g, err := Build(kotsv1beta1.ConfigSpec{
+		Groups: []kotsv1beta1.ConfigGroup{
+			{
+				Name:  "general",
+				Title: `Général "settings" C:\app`,
+				Items: []kotsv1beta1.ConfigItem{
+					{Name: "café"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, `digraph config {
+  rankdir=LR;
+  subgraph cluster_0 {
+    label="Général \"settings\" C:\\app";
+    "café";
+  }
+}
+`, g.DOT())
+}
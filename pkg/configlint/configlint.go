@@ -0,0 +1,110 @@
+// Package configlint statically checks KOTS Config specs for definitions that can never
+// work, such as invalid regex patterns or select_one defaults that aren't an option.
+package configlint
+
+import (
+	"fmt"
+	"regexp"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/pkg/repltemplate"
+)
+
+const (
+	RuleDuplicateItemName          = "config-option-duplicate-name"
+	RuleInvalidRegex               = "config-option-invalid-regex-validator"
+	RuleInvalidSelectOneDefault    = "config-option-invalid-select-one-default"
+	RuleRepeatableMissingTemplates = "repeat-option-missing-template"
+	RuleFilenameOnNonFileType      = "config-option-filename-not-file"
+)
+
+// defaultLevels are the levels findings are reported at when not overridden
+var defaultLevels = map[string]kotsv1beta1.LintLevel{
+	RuleDuplicateItemName:          kotsv1beta1.Error,
+	RuleInvalidRegex:               kotsv1beta1.Error,
+	RuleInvalidSelectOneDefault:    kotsv1beta1.Error,
+	RuleRepeatableMissingTemplates: kotsv1beta1.Warn,
+	RuleFilenameOnNonFileType:      kotsv1beta1.Warn,
+}
+
+// Finding is a single problem found in a Config spec
+type Finding struct {
+	Rule    string
+	Level   kotsv1beta1.LintLevel
+	Group   string
+	Item    string
+	Message string
+}
+
+// LintConfig checks spec against every rule, reporting each finding at the rule's default level.
+// Defaults that contain repl templates are not known until rendered, and are not checked.
+func LintConfig(spec kotsv1beta1.ConfigSpec) []Finding {
+	findings := []Finding{}
+	add := func(rule string, group string, item string, message string) {
+		findings = append(findings, Finding{
+			Rule:    rule,
+			Level:   defaultLevels[rule],
+			Group:   group,
+			Item:    item,
+			Message: message,
+		})
+	}
+
+	// item names are global across groups, since values are keyed by item name only
+	seenItems := map[string]string{}
+
+	for _, group := range spec.Groups {
+		for _, item := range group.Items {
+			if previousGroup, ok := seenItems[item.Name]; ok {
+				add(RuleDuplicateItemName, group.Name, item.Name, fmt.Sprintf("item %q is already defined in group %q", item.Name, previousGroup))
+			} else {
+				seenItems[item.Name] = group.Name
+			}
+
+			if item.Validation != nil && item.Validation.Regex != nil {
+				if _, err := regexp.Compile(item.Validation.Regex.Pattern); err != nil {
+					add(RuleInvalidRegex, group.Name, item.Name, fmt.Sprintf("invalid regex pattern %q: %v", item.Validation.Regex.Pattern, err))
+				}
+			}
+
+			if item.Kind().HasOptions() && !item.Default.IsEmpty() && !repltemplate.IsTemplate(item.Default.String()) && !item.HasOption(item.Default.String()) {
+				add(RuleInvalidSelectOneDefault, group.Name, item.Name, fmt.Sprintf("default %q is not one of the item's options", item.Default.String()))
+			}
+
+			if item.Repeatable && len(item.Templates) == 0 {
+				add(RuleRepeatableMissingTemplates, group.Name, item.Name, "repeatable item has no templates")
+			}
+
+			if item.Filename != "" && item.Kind() != kotsv1beta1.ConfigItemKindFile {
+				add(RuleFilenameOnNonFileType, group.Name, item.Name, fmt.Sprintf("filename is only used by file items, not %q items", item.Type))
+			}
+		}
+	}
+
+	return findings
+}
+
+// ApplyLintConfig sets the level of each finding to the level configured for its rule in
+// lintConfig, and drops findings whose rule is turned off. Rules that aren't configured, or
+// are configured without a level, keep their default level.
+func ApplyLintConfig(findings []Finding, lintConfig kotsv1beta1.LintConfigSpec) []Finding {
+	levels := map[string]kotsv1beta1.LintLevel{}
+	for _, rule := range lintConfig.Rules {
+		if rule.Level != "" {
+			levels[rule.Name] = rule.Level
+		}
+	}
+
+	result := []Finding{}
+	for _, finding := range findings {
+		if level, ok := levels[finding.Rule]; ok {
+			finding.Level = level
+		}
+		if finding.Level == kotsv1beta1.Off {
+			continue
+		}
+		result = append(result, finding)
+	}
+
+	return result
+}
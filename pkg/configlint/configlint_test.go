@@ -0,0 +1,86 @@
+package configlint
+
+import (
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/multitype"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSpec = kotsv1beta1.ConfigSpec{
+	Groups: []kotsv1beta1.ConfigGroup{
+		{
+			Name: "database",
+			Items: []kotsv1beta1.ConfigItem{
+				{Name: "hostname", Type: "text"},
+				{
+					Name:       "port",
+					Type:       "text",
+					Validation: &kotsv1beta1.ConfigItemValidation{Regex: &kotsv1beta1.RegexValidator{Pattern: "[0-9"}},
+				},
+				{
+					Name:    "mode",
+					Type:    "select_one",
+					Default: multitype.FromString("cloud"),
+					Items:   []kotsv1beta1.ConfigChildItem{{Name: "embedded"}, {Name: "external"}},
+				},
+				{
+					Name:    "ok_mode",
+					Type:    "select_one",
+					Default: multitype.FromString("a"),
+					Items:   []kotsv1beta1.ConfigChildItem{{Name: "a"}},
+				},
+				{
+					Name:    "templated_mode",
+					Type:    "select_one",
+					Default: multitype.FromString(`repl{{ ConfigOption "mode" }}`),
+					Items:   []kotsv1beta1.ConfigChildItem{{Name: "a"}},
+				},
+			},
+		},
+		{
+			Name: "advanced",
+			Items: []kotsv1beta1.ConfigItem{
+				{Name: "hostname", Type: "text"},
+				{Name: "hosts", Type: "text", Repeatable: true},
+				{Name: "cert", Type: "textarea", Filename: "cert.pem"},
+				{Name: "key", Type: "file", Filename: "key.pem"},
+			},
+		},
+	},
+}
+
+func TestLintConfig(t *testing.T) {
+	findings := LintConfig(testSpec)
+
+	assert.Equal(t, []Finding{
+		{Rule: RuleInvalidRegex, Level: kotsv1beta1.Error, Group: "database", Item: "port", Message: "invalid regex pattern \"[0-9\": error parsing regexp: missing closing ]: `[0-9`"},
+		{Rule: RuleInvalidSelectOneDefault, Level: kotsv1beta1.Error, Group: "database", Item: "mode", Message: `default "cloud" is not one of the item's options`},
+		{Rule: RuleDuplicateItemName, Level: kotsv1beta1.Error, Group: "advanced", Item: "hostname", Message: `item "hostname" is already defined in group "database"`},
+		{Rule: RuleRepeatableMissingTemplates, Level: kotsv1beta1.Warn, Group: "advanced", Item: "hosts", Message: "repeatable item has no templates"},
+		{Rule: RuleFilenameOnNonFileType, Level: kotsv1beta1.Warn, Group: "advanced", Item: "cert", Message: `filename is only used by file items, not "textarea" items`},
+	}, findings)
+}
+
+func TestApplyLintConfig(t *testing.T) {
+	findings := ApplyLintConfig(LintConfig(testSpec), kotsv1beta1.LintConfigSpec{
+		Rules: []kotsv1beta1.LintRule{
+			{Name: RuleDuplicateItemName, Level: kotsv1beta1.Warn},
+			{Name: RuleRepeatableMissingTemplates, Level: kotsv1beta1.Off},
+			{Name: RuleFilenameOnNonFileType},
+			{Name: "unknown-rule", Level: kotsv1beta1.Off},
+		},
+	})
+
+	levels := map[string]kotsv1beta1.LintLevel{}
+	for _, finding := range findings {
+		levels[finding.Rule] = finding.Level
+	}
+	assert.Equal(t, map[string]kotsv1beta1.LintLevel{
+		RuleInvalidRegex:            kotsv1beta1.Error,
+		RuleInvalidSelectOneDefault: kotsv1beta1.Error,
+		RuleDuplicateItemName:       kotsv1beta1.Warn,
+		RuleFilenameOnNonFileType:   kotsv1beta1.Warn,
+	}, levels)
+}
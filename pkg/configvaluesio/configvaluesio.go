@@ -0,0 +1,67 @@
+// Package configvaluesio converts ConfigValues to and from other formats: env files, JSON or
+// YAML documents grouped by config group, and Helm --set-string arguments.
+//
+// Every format keeps all of the fields of each value, including RepeatableItem, so that
+// reading back what was written gives the same ConfigValuesSpec. Data and DataPlaintext hold
+// raw file contents and are always written base64 encoded. The Value of a file item is
+// already base64 encoded by KOTS and is written as is.
+package configvaluesio
+
+import (
+	"encoding/base64"
+	"sort"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+)
+
+// field is a ConfigValue field that is written as its own key in flat formats
+type field struct {
+	// env is the suffix used in env files, and helm is the key used in helm values.
+	// The value field has no env suffix.
+	env    string
+	helm   string
+	base64 bool
+	get    func(v *kotsv1beta1.ConfigValue) *string
+}
+
+var fields = []field{
+	{env: "", helm: "value", get: func(v *kotsv1beta1.ConfigValue) *string { return &v.Value }},
+	{env: "VALUE_PLAINTEXT", helm: "valuePlaintext", get: func(v *kotsv1beta1.ConfigValue) *string { return &v.ValuePlaintext }},
+	{env: "DEFAULT", helm: "default", get: func(v *kotsv1beta1.ConfigValue) *string { return &v.Default }},
+	{env: "FILENAME", helm: "filename", get: func(v *kotsv1beta1.ConfigValue) *string { return &v.Filename }},
+	{env: "DATA", helm: "data", base64: true, get: func(v *kotsv1beta1.ConfigValue) *string { return &v.Data }},
+	{env: "DATA_PLAINTEXT", helm: "dataPlaintext", base64: true, get: func(v *kotsv1beta1.ConfigValue) *string { return &v.DataPlaintext }},
+	{env: "REPEATABLE_ITEM", helm: "repeatableItem", get: func(v *kotsv1beta1.ConfigValue) *string { return &v.RepeatableItem }},
+}
+
+// encodeField returns the value of a field as it is written
+func encodeField(f field, configValue *kotsv1beta1.ConfigValue) string {
+	value := *f.get(configValue)
+	if f.base64 && value != "" {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	return value
+}
+
+// decodeField sets a field from the value that was written
+func decodeField(f field, configValue *kotsv1beta1.ConfigValue, value string) error {
+	if f.base64 && value != "" {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return errors.Wrap(err, "failed to decode base64")
+		}
+		value = string(decoded)
+	}
+	*f.get(configValue) = value
+	return nil
+}
+
+func sortedNames(spec kotsv1beta1.ConfigValuesSpec) []string {
+	names := make([]string, 0, len(spec.Values))
+	for name := range spec.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,200 @@
+package configvaluesio
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSpec = kotsv1beta1.ConfigValuesSpec{
+	Values: map[string]kotsv1beta1.ConfigValue{
+		"hostname":    {Value: "example.com", Default: "localhost"},
+		"password":    {ValuePlaintext: "p@ss,word=\"1\"\nline"},
+		"cert":        {Value: "Y2VydA==", Filename: "cert.pem", Data: "-----BEGIN-----\n\x00\xff", DataPlaintext: "raw"},
+		"host-1":      {Value: "a.example.com", RepeatableItem: "host"},
+		"dotted.name": {Value: `back\slash`},
+		"empty":       {},
+	},
+}
+
+var testConfig = &kotsv1beta1.Config{
+	Spec: kotsv1beta1.ConfigSpec{
+		Groups: []kotsv1beta1.ConfigGroup{
+			{
+				Name: "settings",
+				Items: []kotsv1beta1.ConfigItem{
+					{Name: "hostname", Type: "text"},
+					{Name: "password", Type: "password"},
+					{Name: "empty", Type: "text"},
+				},
+			},
+			{
+				Name: "files",
+				Items: []kotsv1beta1.ConfigItem{
+					{Name: "cert", Type: "file"},
+					{Name: "host", Type: "text", Repeatable: true},
+				},
+			},
+		},
+	},
+}
+
+func TestEnv(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteEnv(&buf, kotsv1beta1.ConfigValuesSpec{
+		Values: map[string]kotsv1beta1.ConfigValue{
+			"hostname": {Value: "example.com"},
+			"host-1":   {Value: "a", RepeatableItem: "host"},
+			"cert":     {Value: "Y2VydA==", Filename: "cert.pem", Data: "data"},
+		},
+	}))
+	assert.Equal(t, `cert="Y2VydA=="
+cert__FILENAME="cert.pem"
+cert__DATA="ZGF0YQ=="
+host_2D1="a"
+host_2D1__REPEATABLE_ITEM="host"
+hostname="example.com"
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteEnv(&buf, testSpec))
+	spec, err := ReadEnv(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, testSpec, *spec)
+
+	spec, err = ReadEnv(bytes.NewBufferString(`
+# comment
+export a=unquoted value
+b='single'
+b__DEFAULT="x"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]kotsv1beta1.ConfigValue{
+		"a": {Value: "unquoted value"},
+		"b": {Value: "single", Default: "x"},
+	}, spec.Values)
+
+	// keys that decode to the same value
+	_, err = ReadEnv(bytes.NewBufferString("db_host=a\ndb_5Fhost=b\n"))
+	require.Error(t, err)
+
+	_, err = ReadEnv(bytes.NewBufferString("no equals sign"))
+	require.Error(t, err)
+	_, err = ReadEnv(bytes.NewBufferString(`a__DATA="not base64!"`))
+	require.Error(t, err)
+}
+
+func TestEnvNames(t *testing.T) {
+	validKey := regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "db_host", encoded: "db_host"},
+		{name: "host-1", encoded: "host_2D1"},
+		{name: "dotted.name", encoded: "dotted_2Ename"},
+		{name: "1st", encoded: "_31st"},
+		{name: "trailing_", encoded: "trailing_5F"},
+		{name: "cert__FILENAME", encoded: "cert_5F_FILENAME"},
+		{name: "a_2D", encoded: "a_5F2D"},
+		{name: "café", encoded: "caf_C3_A9"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded := encodeEnvName(test.name)
+			assert.Equal(t, test.encoded, encoded)
+			assert.Regexp(t, validKey, encoded)
+			assert.NotContains(t, encoded, envSeparator)
+			assert.Equal(t, test.name, decodeEnvName(encoded))
+		})
+	}
+
+	// a value named like another value's field is kept apart from it
+	spec := kotsv1beta1.ConfigValuesSpec{
+		Values: map[string]kotsv1beta1.ConfigValue{
+			"cert":           {Value: "a", Filename: "cert.pem"},
+			"cert__FILENAME": {Value: "b"},
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, WriteEnv(&buf, spec))
+	read, err := ReadEnv(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, spec, *read)
+}
+
+func TestGrouped(t *testing.T) {
+	grouped := Group(testSpec, testConfig)
+	assert.Len(t, grouped, 3)
+	assert.Len(t, grouped["settings"], 3)
+	assert.Contains(t, grouped["files"], "host-1")
+	assert.Contains(t, grouped["files"], "cert")
+	assert.Contains(t, grouped[UngroupedKey], "dotted.name")
+	assert.Equal(t, "LS0tLS1CRUdJTi0tLS0tCgD/", grouped["files"]["cert"].Data)
+
+	data, err := MarshalGroupedJSON(testSpec, testConfig)
+	require.NoError(t, err)
+	spec, err := UnmarshalGrouped(data)
+	require.NoError(t, err)
+	assert.Equal(t, testSpec, *spec)
+
+	data, err = MarshalGroupedYAML(testSpec, testConfig)
+	require.NoError(t, err)
+	spec, err = UnmarshalGrouped(data)
+	require.NoError(t, err)
+	assert.Equal(t, testSpec, *spec)
+
+	_, err = GroupedValues{
+		"a": {"x": {Value: "1"}},
+		"b": {"x": {Value: "2"}},
+	}.Ungroup()
+	require.Error(t, err)
+}
+
+func TestHelmSetArgs(t *testing.T) {
+	assert.Equal(t, []string{
+		`dotted\.name.value=a\,b`,
+		"host-1.value=a",
+		"host-1.repeatableItem=host",
+	}, HelmSetArgs(kotsv1beta1.ConfigValuesSpec{
+		Values: map[string]kotsv1beta1.ConfigValue{
+			"dotted.name": {Value: "a,b"},
+			"host-1":      {Value: "a", RepeatableItem: "host"},
+		},
+	}))
+
+	spec, err := ParseHelmSetArgs(HelmSetArgs(testSpec))
+	require.NoError(t, err)
+	assert.Equal(t, testSpec, *spec)
+
+	// brackets would be list indexes to helm
+	bracketed := kotsv1beta1.ConfigValuesSpec{
+		Values: map[string]kotsv1beta1.ConfigValue{
+			"hosts[0]":  {Value: "a"},
+			"weird]=[,": {Value: "b"},
+		},
+	}
+	args := HelmSetArgs(bracketed)
+	assert.Equal(t, []string{`hosts\[0\].value=a`, `weird\]\=\[\,.value=b`}, args)
+	spec, err = ParseHelmSetArgs(args)
+	require.NoError(t, err)
+	assert.Equal(t, bracketed, *spec)
+
+	spec, err = ParseHelmSetArgs([]string{"a.value=1,a.default=2", "b.filename=b.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]kotsv1beta1.ConfigValue{
+		"a": {Value: "1", Default: "2"},
+		"b": {Filename: "b.txt"},
+	}, spec.Values)
+
+	for _, arg := range []string{"a=1", "a.unknown=1", "a.value", `a.value=1\`, ".value=1"} {
+		_, err := ParseHelmSetArgs([]string{arg})
+		assert.Error(t, err, arg)
+	}
+}
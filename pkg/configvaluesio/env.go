@@ -0,0 +1,188 @@
+package configvaluesio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+)
+
+// envSeparator separates a value name from the field suffix in env file keys
+const envSeparator = "__"
+
+// WriteEnv writes spec as an env file. Each value is written as `name="value"`, followed by a
+// `name__FIELD="..."` line for each of its other fields that is set, such as
+// `name__REPEATABLE_ITEM` or `name__FILENAME`. Values are double quoted and escaped like Go
+// strings, so they can contain newlines. Names are written in sorted order.
+//
+// Names are encoded into valid env var names, see encodeEnvName, so `host-1` is written as
+// `host_2D1`. An error is returned if two names would be written as the same key.
+func WriteEnv(w io.Writer, spec kotsv1beta1.ConfigValuesSpec) error {
+	encodedNames := map[string]string{}
+	for _, name := range sortedNames(spec) {
+		encoded := encodeEnvName(name)
+		if other, ok := encodedNames[encoded]; ok {
+			return errors.Errorf("values %q and %q are both written as %q", other, name, encoded)
+		}
+		encodedNames[encoded] = name
+	}
+
+	for _, name := range sortedNames(spec) {
+		configValue := spec.Values[name]
+		for _, f := range fields {
+			value := encodeField(f, &configValue)
+			if value == "" && f.env != "" {
+				continue
+			}
+
+			key := encodeEnvName(name)
+			if f.env != "" {
+				key += envSeparator + f.env
+			}
+			if _, err := fmt.Fprintf(w, "%s=%s\n", key, strconv.Quote(value)); err != nil {
+				return errors.Wrap(err, "failed to write")
+			}
+		}
+	}
+	return nil
+}
+
+// ReadEnv reads an env file written by WriteEnv. Blank lines and lines starting with # are
+// ignored, and values may be unquoted, single quoted or double quoted. An error is returned
+// when two different keys decode to the same name and field.
+func ReadEnv(r io.Reader) (*kotsv1beta1.ConfigValuesSpec, error) {
+	spec := &kotsv1beta1.ConfigValuesSpec{
+		Values: map[string]kotsv1beta1.ConfigValue{},
+	}
+	keys := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, rawValue, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, errors.Errorf("line %d: expected key=value", lineNumber)
+		}
+		key = strings.TrimSpace(key)
+
+		value, err := unquoteEnvValue(strings.TrimSpace(rawValue))
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNumber)
+		}
+
+		name, f := parseEnvKey(key)
+		if name == "" {
+			return nil, errors.Errorf("line %d: empty name", lineNumber)
+		}
+		if other, ok := keys[name+envSeparator+f.env]; ok && other != key {
+			return nil, errors.Errorf("line %d: %s sets the same value as %s", lineNumber, key, other)
+		}
+		keys[name+envSeparator+f.env] = key
+
+		configValue := spec.Values[name]
+		if err := decodeField(f, &configValue, value); err != nil {
+			return nil, errors.Wrapf(err, "line %d: %s", lineNumber, key)
+		}
+		spec.Values[name] = configValue
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read")
+	}
+
+	return spec, nil
+}
+
+// parseEnvKey splits an env file key into the decoded value name and the field it sets
+func parseEnvKey(key string) (string, field) {
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if name := strings.TrimSuffix(key, envSeparator+f.env); name != key {
+			return decodeEnvName(name), f
+		}
+	}
+	return decodeEnvName(key), fields[0]
+}
+
+// encodeEnvName encodes a value name as a valid env var name. Letters and digits are kept,
+// and every other byte is written as _ followed by two upper case hex digits, as is a
+// leading digit. An underscore is kept when it is followed by a letter or digit that could
+// not be read as an escape, so most names with underscores are unchanged. The result never
+// contains envSeparator, so it can't be mistaken for a field suffix.
+func encodeEnvName(name string) string {
+	var sb strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case isEnvAlnum(c) && !(i == 0 && isDigit(c)):
+			sb.WriteByte(c)
+		case c == '_' && i+1 < len(name) && isEnvAlnum(name[i+1]) && !isEnvEscape(name[i+1:]):
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "_%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// decodeEnvName reverses encodeEnvName. Underscores that do not start an escape are kept,
+// so keys written by hand such as `db_host` or `host-1` are read as they are.
+func decodeEnvName(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		if key[i] == '_' && isEnvEscape(key[i+1:]) {
+			b, _ := strconv.ParseUint(key[i+1:i+3], 16, 8)
+			sb.WriteByte(byte(b))
+			i += 2
+			continue
+		}
+		sb.WriteByte(key[i])
+	}
+	return sb.String()
+}
+
+// isEnvEscape reports whether s starts with two upper case hex digits
+func isEnvEscape(s string) bool {
+	return len(s) >= 2 && isUpperHex(s[0]) && isUpperHex(s[1])
+}
+
+func isEnvAlnum(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isUpperHex(c byte) bool {
+	return isDigit(c) || (c >= 'A' && c <= 'F')
+}
+
+func unquoteEnvValue(value string) (string, error) {
+	if len(value) >= 2 {
+		switch {
+		case value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return "", errors.Wrap(err, "invalid quoted value")
+			}
+			return unquoted, nil
+		case value[0] == '\'' && value[len(value)-1] == '\'':
+			return value[1 : len(value)-1], nil
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,112 @@
+package configvaluesio
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+// UngroupedKey is the group key used for values whose item is not in the config
+const UngroupedKey = ""
+
+// GroupedValues are config values keyed by config group name, then by value name. Each
+// value has the same fields as a ConfigValue, with Data and DataPlaintext base64 encoded.
+type GroupedValues map[string]map[string]kotsv1beta1.ConfigValue
+
+// Group returns the values in spec grouped by the config group of their item. Repeated values
+// are placed in the group of their repeatable item. Values whose item is not in config are
+// placed under UngroupedKey.
+func Group(spec kotsv1beta1.ConfigValuesSpec, config *kotsv1beta1.Config) GroupedValues {
+	groupOf := map[string]string{}
+	if config != nil {
+		for _, group := range config.Spec.Groups {
+			for _, item := range group.Items {
+				if _, ok := groupOf[item.Name]; !ok {
+					groupOf[item.Name] = group.Name
+				}
+			}
+		}
+	}
+
+	grouped := GroupedValues{}
+	for name, configValue := range spec.Values {
+		itemName := name
+		if configValue.RepeatableItem != "" {
+			itemName = configValue.RepeatableItem
+		}
+
+		group, ok := groupOf[itemName]
+		if !ok {
+			group = UngroupedKey
+		}
+
+		encoded := kotsv1beta1.ConfigValue{}
+		for _, f := range fields {
+			*f.get(&encoded) = encodeField(f, &configValue)
+		}
+
+		if grouped[group] == nil {
+			grouped[group] = map[string]kotsv1beta1.ConfigValue{}
+		}
+		grouped[group][name] = encoded
+	}
+
+	return grouped
+}
+
+// Ungroup is the reverse of Group
+func (g GroupedValues) Ungroup() (*kotsv1beta1.ConfigValuesSpec, error) {
+	spec := &kotsv1beta1.ConfigValuesSpec{
+		Values: map[string]kotsv1beta1.ConfigValue{},
+	}
+
+	for group, values := range g {
+		for name, encoded := range values {
+			if _, exists := spec.Values[name]; exists {
+				return nil, errors.Errorf("value %s is in more than one group", name)
+			}
+
+			configValue := kotsv1beta1.ConfigValue{}
+			for _, f := range fields {
+				if err := decodeField(f, &configValue, *f.get(&encoded)); err != nil {
+					return nil, errors.Wrapf(err, "failed to read %s in group %q", name, group)
+				}
+			}
+			spec.Values[name] = configValue
+		}
+	}
+
+	return spec, nil
+}
+
+// MarshalGroupedJSON returns the values in spec as a JSON document grouped by config group.
+// See Group.
+func MarshalGroupedJSON(spec kotsv1beta1.ConfigValuesSpec, config *kotsv1beta1.Config) ([]byte, error) {
+	data, err := json.MarshalIndent(Group(spec, config), "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal json")
+	}
+	return data, nil
+}
+
+// MarshalGroupedYAML returns the values in spec as a YAML document grouped by config group.
+// See Group.
+func MarshalGroupedYAML(spec kotsv1beta1.ConfigValuesSpec, config *kotsv1beta1.Config) ([]byte, error) {
+	data, err := yaml.Marshal(Group(spec, config))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal yaml")
+	}
+	return data, nil
+}
+
+// UnmarshalGrouped reads a JSON or YAML document written by MarshalGroupedJSON or
+// MarshalGroupedYAML
+func UnmarshalGrouped(data []byte) (*kotsv1beta1.ConfigValuesSpec, error) {
+	grouped := GroupedValues{}
+	if err := yaml.Unmarshal(data, &grouped); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal")
+	}
+	return grouped.Ungroup()
+}
@@ -0,0 +1,129 @@
+package configvaluesio
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+)
+
+// HelmSetArgs returns the values in spec as arguments for helm's --set-string flag, in the
+// form `name.field=value`, such as `hostname.value=example.com` or
+// `host-1.repeatableItem=host`. The value field is always included, other fields only when
+// they are set. Dots, brackets, equals signs and commas in names, and commas in values, are
+// escaped with a backslash, as helm would otherwise read them as nesting, list indexes or
+// separators. Names are written in sorted order.
+func HelmSetArgs(spec kotsv1beta1.ConfigValuesSpec) []string {
+	args := []string{}
+	for _, name := range sortedNames(spec) {
+		configValue := spec.Values[name]
+		for _, f := range fields {
+			value := encodeField(f, &configValue)
+			if value == "" && f.helm != "value" {
+				continue
+			}
+			args = append(args, escapeHelmKey(name)+"."+f.helm+"="+escapeHelmValue(value))
+		}
+	}
+	return args
+}
+
+// ParseHelmSetArgs reads arguments written by HelmSetArgs. Each argument may hold several
+// comma separated assignments, as helm allows.
+func ParseHelmSetArgs(args []string) (*kotsv1beta1.ConfigValuesSpec, error) {
+	spec := &kotsv1beta1.ConfigValuesSpec{
+		Values: map[string]kotsv1beta1.ConfigValue{},
+	}
+
+	for _, arg := range args {
+		assignments, err := splitHelmAssignments(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %q", arg)
+		}
+
+		for _, assignment := range assignments {
+			name, fieldName := assignment[0], assignment[1]
+			var f *field
+			for i := range fields {
+				if fields[i].helm == fieldName {
+					f = &fields[i]
+				}
+			}
+			if f == nil {
+				return nil, errors.Errorf("unknown field %q for %s", fieldName, name)
+			}
+
+			configValue := spec.Values[name]
+			if err := decodeField(*f, &configValue, assignment[2]); err != nil {
+				return nil, errors.Wrapf(err, "failed to read %s.%s", name, fieldName)
+			}
+			spec.Values[name] = configValue
+		}
+	}
+
+	return spec, nil
+}
+
+// splitHelmAssignments splits `a.value=x,b\.c.value=y\,z` into name, field and value
+// triples, removing escapes
+func splitHelmAssignments(arg string) ([][3]string, error) {
+	assignments := [][3]string{}
+
+	var current strings.Builder
+	parts := []string{}
+	inValue := false
+	escaped := false
+
+	finish := func() error {
+		if !inValue {
+			return errors.New("expected key=value")
+		}
+		if len(parts) != 2 || parts[0] == "" {
+			return errors.New("expected name.field as key")
+		}
+		assignments = append(assignments, [3]string{parts[0], parts[1], current.String()})
+		current.Reset()
+		parts = []string{}
+		inValue = false
+		return nil
+	}
+
+	for _, r := range arg {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case !inValue && r == '.':
+			parts = append(parts, current.String())
+			current.Reset()
+		case !inValue && r == '=':
+			parts = append(parts, current.String())
+			current.Reset()
+			inValue = true
+		case inValue && r == ',':
+			if err := finish(); err != nil {
+				return nil, err
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+func escapeHelmKey(key string) string {
+	return strings.NewReplacer(`\`, `\\`, ".", `\.`, "[", `\[`, "]", `\]`, "=", `\=`, ",", `\,`).Replace(key)
+}
+
+func escapeHelmValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`).Replace(value)
+}
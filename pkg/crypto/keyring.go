@@ -0,0 +1,64 @@
+package crypto
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Keyring encrypts and decrypts data, such as sensitive config values
+type Keyring interface {
+	Encrypt(in []byte) []byte
+	Decrypt(in []byte) ([]byte, error)
+}
+
+// DefaultKeyring returns a Keyring backed by the globally registered keys, the same ones
+// used by Encrypt and Decrypt
+func DefaultKeyring() Keyring {
+	return globalKeyring{}
+}
+
+type globalKeyring struct{}
+
+func (globalKeyring) Encrypt(in []byte) []byte {
+	return Encrypt(in)
+}
+
+func (globalKeyring) Decrypt(in []byte) ([]byte, error) {
+	return Decrypt(in)
+}
+
+type keyring struct {
+	ciphers []*aesCipher
+}
+
+// NewKeyring returns a Keyring that uses only the provided keys, in the format returned by
+// ToString. The first key is used for encryption, and every key is tried for decryption.
+func NewKeyring(keys ...string) (Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+
+	k := &keyring{}
+	for i, key := range keys {
+		keyCipher, err := aesCipherFromString(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse key %d", i)
+		}
+		k.ciphers = append(k.ciphers, keyCipher)
+	}
+
+	return k, nil
+}
+
+func (k *keyring) Encrypt(in []byte) []byte {
+	return k.ciphers[0].cipher.Seal(nil, k.ciphers[0].nonce, in, nil)
+}
+
+func (k *keyring) Decrypt(in []byte) (result []byte, err error) {
+	for _, decryptCipher := range k.ciphers {
+		result, err = decryptCipher.decrypt(in)
+		if err == nil {
+			return result, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,51 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewKeyring(t *testing.T) {
+	req := require.New(t)
+
+	altCipher := "wwYTl3RHaCirSqx7alC/hsRQXyycHDdGZZCyNMy9R01p5czC"
+	altCiphertext := "sNrI1egS1iLGesPDecd8G7WoNyE/KL7IFR6mYPzWwZLY5xCC"
+	altPlaintext := "this is a test value"
+
+	_, err := NewKeyring()
+	req.Error(err)
+
+	_, err = NewKeyring("not a key")
+	req.Error(err)
+
+	k, err := NewKeyring(altCipher)
+	req.NoError(err)
+
+	altCipherBytes, err := base64.StdEncoding.DecodeString(altCiphertext)
+	req.NoError(err)
+
+	decrypted, err := k.Decrypt(altCipherBytes)
+	req.NoError(err)
+	req.Equal(altPlaintext, string(decrypted))
+	req.Equal(altCiphertext, base64.StdEncoding.EncodeToString(k.Encrypt([]byte(altPlaintext))))
+
+	_, err = k.Decrypt([]byte("not encrypted"))
+	req.Error(err)
+
+	// the first key encrypts, all keys decrypt
+	encryptionCipher = nil
+	decryptionCiphers = nil
+	req.NoError(NewAESCipher())
+	other, err := NewKeyring(ToString(), altCipher)
+	req.NoError(err)
+
+	decrypted, err = other.Decrypt(altCipherBytes)
+	req.NoError(err)
+	req.Equal(altPlaintext, string(decrypted))
+
+	decrypted, err = DefaultKeyring().Decrypt(other.Encrypt([]byte("value")))
+	req.NoError(err)
+	req.Equal("value", string(decrypted))
+}
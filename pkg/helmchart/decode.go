@@ -0,0 +1,100 @@
+package helmchart
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	kotsv1beta2 "github.com/replicatedhq/kotskinds/apis/kots/v1beta2"
+	kotsscheme "github.com/replicatedhq/kotskinds/client/kotsclientset/scheme"
+	"github.com/replicatedhq/kotskinds/pkg/internal/yamldoc"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+func init() {
+	kotsscheme.AddToScheme(scheme.Scheme)
+}
+
+// Document is a HelmChart found in a multi-document YAML stream, along with its
+// position in that stream.
+type Document struct {
+	HelmChart HelmChartInterface
+	// DocumentIndex is the zero-based index of the document in the stream
+	DocumentIndex int
+	// Line is the one-based line at which the document starts
+	Line int
+}
+
+// Decode deserializes HelmChart YAML/JSON bytes into a HelmChartInterface.
+// It automatically detects whether the data contains a v1beta1 or v1beta2 HelmChart.
+func Decode(data []byte) (HelmChartInterface, error) {
+	var typeMeta metav1.TypeMeta
+	if err := yaml.Unmarshal(data, &typeMeta); err != nil {
+		return nil, errors.Wrap(err, "failed to parse type metadata")
+	}
+
+	if !isKotsHelmChart(typeMeta) {
+		return nil, &UnexpectedKindError{APIVersion: typeMeta.APIVersion, Kind: typeMeta.Kind}
+	}
+
+	gv, err := schema.ParseGroupVersion(typeMeta.APIVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse api version")
+	}
+	if gv.Version != "v1beta1" && gv.Version != "v1beta2" {
+		return nil, &UnsupportedVersionError{Version: gv.Version}
+	}
+
+	decode := scheme.Codecs.UniversalDeserializer().Decode
+	obj, _, err := decode(data, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode helm chart data")
+	}
+
+	switch helmChart := obj.(type) {
+	case *kotsv1beta1.HelmChart:
+		return helmChart, nil
+	case *kotsv1beta2.HelmChart:
+		return helmChart, nil
+	default:
+		return nil, errors.Errorf("unexpected object type %T", obj)
+	}
+}
+
+// DecodeAll returns every kots.io HelmChart in a multi-document YAML stream, such
+// as a release. Documents of other kinds are skipped. An error decoding a HelmChart
+// document is returned as a *DocumentError carrying the document's position.
+func DecodeAll(data []byte) ([]Document, error) {
+	documents := []Document{}
+
+	for _, doc := range yamldoc.Split(data) {
+		var typeMeta metav1.TypeMeta
+		if err := yaml.Unmarshal(doc.Data, &typeMeta); err != nil {
+			// not every document in a release is valid kubernetes yaml
+			continue
+		}
+		if !isKotsHelmChart(typeMeta) {
+			continue
+		}
+
+		helmChart, err := Decode(doc.Data)
+		if err != nil {
+			return nil, &DocumentError{DocumentIndex: doc.Index, Line: doc.Line, Err: err}
+		}
+
+		documents = append(documents, Document{
+			HelmChart:     helmChart,
+			DocumentIndex: doc.Index,
+			Line:          doc.Line,
+		})
+	}
+
+	return documents, nil
+}
+
+func isKotsHelmChart(typeMeta metav1.TypeMeta) bool {
+	return strings.HasPrefix(typeMeta.APIVersion, "kots.io/") && typeMeta.Kind == "HelmChart"
+}
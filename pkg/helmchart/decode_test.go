@@ -0,0 +1,178 @@
+package helmchart
+
+import (
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	kotsv1beta2 "github.com/replicatedhq/kotskinds/apis/kots/v1beta2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	v1beta1HelmChartYAML = `apiVersion: kots.io/v1beta1
+kind: HelmChart
+metadata:
+  name: postgresql
+spec:
+  chart:
+    name: postgresql
+    chartVersion: 12.1.7
+    releaseName: db
+  helmVersion: v3
+  namespace: data
+  weight: 10
+`
+
+	v1beta2HelmChartYAML = `apiVersion: kots.io/v1beta2
+kind: HelmChart
+metadata:
+  name: redis
+spec:
+  chart:
+    name: redis
+    chartVersion: 17.4.0
+  releaseName: cache
+  namespace: data
+`
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		wantAPIVersion  string
+		wantChartName   string
+		wantReleaseName string
+	}{
+		{
+			name:            "v1beta1",
+			input:           v1beta1HelmChartYAML,
+			wantAPIVersion:  "kots.io/v1beta1",
+			wantChartName:   "postgresql",
+			wantReleaseName: "db",
+		},
+		{
+			name:            "v1beta2",
+			input:           v1beta2HelmChartYAML,
+			wantAPIVersion:  "kots.io/v1beta2",
+			wantChartName:   "redis",
+			wantReleaseName: "cache",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helmChart, err := Decode([]byte(tt.input))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantAPIVersion, helmChart.GetAPIVersion())
+			assert.Equal(t, tt.wantChartName, helmChart.GetChartName())
+			assert.Equal(t, tt.wantReleaseName, helmChart.GetReleaseName())
+			assert.Equal(t, "data", helmChart.GetNamespace())
+		})
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name                   string
+		input                  string
+		wantUnsupportedVersion bool
+		wantUnexpectedKind     bool
+	}{
+		{
+			name:  "invalid yaml",
+			input: "not: valid: yaml: [",
+		},
+		{
+			name: "unsupported version",
+			input: `apiVersion: kots.io/v1beta3
+kind: HelmChart
+metadata:
+  name: test
+`,
+			wantUnsupportedVersion: true,
+		},
+		{
+			name: "wrong kind",
+			input: `apiVersion: kots.io/v1beta1
+kind: Application
+metadata:
+  name: test
+`,
+			wantUnexpectedKind: true,
+		},
+		{
+			name: "wrong group",
+			input: `apiVersion: helm.cattle.io/v1
+kind: HelmChart
+metadata:
+  name: test
+`,
+			wantUnexpectedKind: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode([]byte(tt.input))
+			require.Error(t, err)
+			assert.Equal(t, tt.wantUnsupportedVersion, IsUnsupportedVersionError(err))
+			assert.Equal(t, tt.wantUnexpectedKind, IsUnexpectedKindError(err))
+		})
+	}
+}
+
+func TestDecodeAll(t *testing.T) {
+	stream := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+` + v1beta1HelmChartYAML + `---
+# a comment-only document is skipped
+
+---
+this is: [not kubernetes yaml
+--- # redis
+` + v1beta2HelmChartYAML
+
+	documents, err := DecodeAll([]byte(stream))
+	require.NoError(t, err)
+	require.Len(t, documents, 2)
+
+	assert.Equal(t, 1, documents[0].DocumentIndex)
+	assert.Equal(t, 6, documents[0].Line)
+	assert.IsType(t, &kotsv1beta1.HelmChart{}, documents[0].HelmChart)
+	assert.Equal(t, "postgresql", documents[0].HelmChart.GetChartName())
+
+	assert.Equal(t, 4, documents[1].DocumentIndex)
+	assert.Equal(t, 24, documents[1].Line)
+	assert.IsType(t, &kotsv1beta2.HelmChart{}, documents[1].HelmChart)
+	assert.Equal(t, "redis", documents[1].HelmChart.GetChartName())
+}
+
+func TestDecodeAll_UnsupportedVersion(t *testing.T) {
+	stream := v1beta1HelmChartYAML + `---
+apiVersion: kots.io/v1beta3
+kind: HelmChart
+metadata:
+  name: future
+`
+
+	_, err := DecodeAll([]byte(stream))
+	require.Error(t, err)
+	assert.True(t, IsUnsupportedVersionError(err))
+
+	var docErr *DocumentError
+	require.ErrorAs(t, err, &docErr)
+	assert.Equal(t, 1, docErr.DocumentIndex)
+	assert.Equal(t, 14, docErr.Line)
+}
+
+func TestDecodeAll_Empty(t *testing.T) {
+	documents, err := DecodeAll([]byte(""))
+	require.NoError(t, err)
+	assert.Empty(t, documents)
+}
@@ -0,0 +1,55 @@
+package helmchart
+
+import (
+	"errors"
+	"fmt"
+)
+
+// UnsupportedVersionError is returned when a kots.io HelmChart declares an
+// apiVersion that this package does not know how to decode.
+type UnsupportedVersionError struct {
+	Version string
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("unsupported HelmChart version: %s", e.Version)
+}
+
+func IsUnsupportedVersionError(err error) bool {
+	var uve *UnsupportedVersionError
+	return errors.As(err, &uve)
+}
+
+// UnexpectedKindError is returned when a document is decoded successfully but is
+// not a kots.io HelmChart.
+type UnexpectedKindError struct {
+	APIVersion string
+	Kind       string
+}
+
+func (e *UnexpectedKindError) Error() string {
+	return fmt.Sprintf("expected kots.io HelmChart, got %s %s", e.APIVersion, e.Kind)
+}
+
+func IsUnexpectedKindError(err error) bool {
+	var uke *UnexpectedKindError
+	return errors.As(err, &uke)
+}
+
+// DocumentError wraps an error encountered while decoding one document of a
+// multi-document YAML stream, recording where in the stream the document starts.
+type DocumentError struct {
+	// DocumentIndex is the zero-based index of the document in the stream
+	DocumentIndex int
+	// Line is the one-based line at which the document starts
+	Line int
+	Err  error
+}
+
+func (e *DocumentError) Error() string {
+	return fmt.Sprintf("document %d (line %d): %v", e.DocumentIndex, e.Line, e.Err)
+}
+
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,161 @@
+package helmchart
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// valuesSchemaURL is the location the values schema is registered at in the compiler. Only
+// references within this document can be resolved.
+const valuesSchemaURL = "file:///values.schema.json"
+
+// schemaError is a single failure reported when validating values against a schema
+type schemaError struct {
+	path    string
+	message string
+}
+
+// noRemoteLoader refuses to load any schema other than the values schema, so that a
+// chart can't reference remote or local files
+type noRemoteLoader struct{}
+
+func (noRemoteLoader) Load(url string) (any, error) {
+	return nil, errors.Errorf("unsupported $ref %q: only references within the values schema are supported", url)
+}
+
+// compileValuesSchema compiles a values.schema.json. The draft is taken from $schema, and
+// defaults to draft-07 as in Helm.
+func compileValuesSchema(valuesSchema []byte) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(valuesSchema))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse values schema")
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft7)
+	compiler.UseLoader(noRemoteLoader{})
+	if err := compiler.AddResource(valuesSchemaURL, doc); err != nil {
+		return nil, errors.Wrap(err, "failed to add values schema")
+	}
+
+	schema, err := compiler.Compile(valuesSchemaURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compile values schema")
+	}
+	return schema, nil
+}
+
+func validateAgainstSchema(schema *jsonschema.Schema, values map[string]interface{}) ([]schemaError, error) {
+	// Helm drops null values before validating, so they count as absent
+	instance := withoutNulls(values)
+
+	err := schema.Validate(instance)
+	if err == nil {
+		return []schemaError{}, nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, errors.Wrap(err, "failed to validate values")
+	}
+
+	printer := message.NewPrinter(language.English)
+	result := []schemaError{}
+	for _, leaf := range unexplainedErrors(validationErr, instance) {
+		_, path := lookupInstance(instance, leaf.InstanceLocation)
+		result = append(result, schemaError{path: path, message: leaf.ErrorKind.LocalizedString(printer)})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].path < result[j].path
+	})
+	return result, nil
+}
+
+// unexplainedErrors returns the failures in the tree rooted at validationErr that are not
+// caused by repl templates. Templates are rendered at install time, so a templated value
+// could satisfy any schema. Failures at a templated value are dropped, as are anyOf and
+// oneOf failures where one of the subschemas only failed because of templates. anyOf and
+// oneOf failures are reported once rather than per subschema.
+func unexplainedErrors(validationErr *jsonschema.ValidationError, instance interface{}) []*jsonschema.ValidationError {
+	if len(validationErr.Causes) == 0 {
+		if value, _ := lookupInstance(instance, validationErr.InstanceLocation); isReplTemplate(value) {
+			return nil
+		}
+		return []*jsonschema.ValidationError{validationErr}
+	}
+
+	switch validationErr.ErrorKind.(type) {
+	case *kind.AnyOf, *kind.OneOf:
+		for _, cause := range validationErr.Causes {
+			if len(unexplainedErrors(cause, instance)) == 0 {
+				return nil
+			}
+		}
+		return []*jsonschema.ValidationError{validationErr}
+	}
+
+	result := []*jsonschema.ValidationError{}
+	for _, cause := range validationErr.Causes {
+		result = append(result, unexplainedErrors(cause, instance)...)
+	}
+	return result
+}
+
+// lookupInstance returns the value at location in instance, and the location formatted as
+// a values path such as "ingress.hosts[0]"
+func lookupInstance(instance interface{}, location []string) (interface{}, string) {
+	path := ""
+	current := instance
+	for _, token := range location {
+		switch node := current.(type) {
+		case []interface{}:
+			path += "[" + token + "]"
+			current = nil
+			if index, err := strconv.Atoi(token); err == nil && index >= 0 && index < len(node) {
+				current = node[index]
+			}
+		case map[string]interface{}:
+			path = joinPath(path, token)
+			current = node[token]
+		default:
+			path = joinPath(path, token)
+			current = nil
+		}
+	}
+	return current, path
+}
+
+func withoutNulls(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			if v != nil {
+				result[k] = withoutNulls(v)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, v := range typed {
+			result[i] = withoutNulls(v)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,132 @@
+package helmchart
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kotskinds/pkg/repltemplate"
+)
+
+// TemplatedValue is a single value in a HelmChart spec that contains repl templates.
+type TemplatedValue struct {
+	// Path locates the value in the spec, e.g. "values.ingress.enabled",
+	// "optionalValues[0].when", "optionalValues[0].values.hosts[1]" or "exclude"
+	Path     string
+	Template string
+	// Functions, ConfigItems and LicenseFields are what the template references,
+	// see repltemplate.Analysis
+	Functions     []string
+	ConfigItems   []string
+	LicenseFields []string
+	// ParseError is set when the template could not be parsed, in which case nothing
+	// is known about what it references
+	ParseError error
+}
+
+// TemplateInventory lists every templated value in a HelmChart.
+type TemplateInventory struct {
+	Values []TemplatedValue
+}
+
+// ConfigItems returns every config item name referenced by the inventory, sorted.
+func (i *TemplateInventory) ConfigItems() []string {
+	return i.collect(func(v TemplatedValue) []string { return v.ConfigItems })
+}
+
+// LicenseFields returns every license field name referenced by the inventory, sorted.
+func (i *TemplateInventory) LicenseFields() []string {
+	return i.collect(func(v TemplatedValue) []string { return v.LicenseFields })
+}
+
+// Functions returns every template function called by the inventory, sorted.
+func (i *TemplateInventory) Functions() []string {
+	return i.collect(func(v TemplatedValue) []string { return v.Functions })
+}
+
+func (i *TemplateInventory) collect(field func(TemplatedValue) []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, v := range i.Values {
+		for _, name := range field(v) {
+			if !seen[name] {
+				seen[name] = true
+				result = append(result, name)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// InventoryTemplates lists every templated leaf in spec.values, spec.optionalValues[].when,
+// spec.optionalValues[].values and spec.exclude, along with the template functions, config
+// items and license fields each one references. Unlike HelmChartSpec.GetReplTmplValues, the
+// result covers the whole spec and records where each template was found.
+//
+// A template that fails to parse is still listed, with ParseError set.
+func InventoryTemplates(helmChart HelmChartInterface) (*TemplateInventory, error) {
+	spec, err := renderSpec(helmChart)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get helm chart spec")
+	}
+
+	inventory := &TemplateInventory{Values: []TemplatedValue{}}
+
+	inventory.addValues("values", spec.values)
+
+	for _, optionalValue := range spec.optionalValues {
+		prefix := fmt.Sprintf("optionalValues[%d]", optionalValue.index)
+		inventory.add(prefix+".when", optionalValue.when)
+		if optionalValue.valuesString != "" {
+			inventory.add(prefix+".values", optionalValue.valuesString)
+		}
+		inventory.addValues(prefix+".values", optionalValue.values)
+	}
+
+	inventory.add("exclude", spec.exclude)
+
+	return inventory, nil
+}
+
+func (i *TemplateInventory) addValues(path string, value interface{}) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typed))
+		for k := range typed {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			i.addValues(joinPath(path, k), typed[k])
+		}
+	case []interface{}:
+		for index, element := range typed {
+			i.addValues(fmt.Sprintf("%s[%d]", path, index), element)
+		}
+	case string:
+		i.add(path, typed)
+	}
+}
+
+func (i *TemplateInventory) add(path string, template string) {
+	if !repltemplate.IsTemplate(template) {
+		return
+	}
+
+	templatedValue := TemplatedValue{
+		Path:     path,
+		Template: template,
+	}
+
+	analysis, err := repltemplate.Analyze(template)
+	if err != nil {
+		templatedValue.ParseError = err
+	} else {
+		templatedValue.Functions = analysis.Functions
+		templatedValue.ConfigItems = analysis.ConfigItems
+		templatedValue.LicenseFields = analysis.LicenseFields
+	}
+
+	i.Values = append(i.Values, templatedValue)
+}
@@ -0,0 +1,83 @@
+package helmchart
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventoryTemplates(t *testing.T) {
+	data := `apiVersion: kots.io/v1beta2
+kind: HelmChart
+metadata:
+  name: app
+spec:
+  chart:
+    name: app
+    chartVersion: 1.0.0
+  exclude: repl{{ ConfigOptionEquals "deploy_app" "0" }}
+  values:
+    replicas: 1
+    hostname: repl{{ ConfigOption "hostname" }}
+    seats: '{{repl LicenseFieldValue "seats" }}'
+    image:
+      registry: '{{repl if HasLocalRegistry }}{{repl LocalRegistryHost }}{{repl else }}registry.example.com{{repl end }}'
+    hosts:
+      - static
+      - repl{{ ConfigOption "extra_host" }}
+  optionalValues:
+    - when: repl{{ ConfigOptionEquals "tls" "1" }}
+      values:
+        tls:
+          cert: repl{{ ConfigOptionData "tls_cert" }}
+    - when: repl{{ ConfigOption "broken" 
+      values:
+        a: b
+`
+
+	helmChart, err := Decode([]byte(data))
+	require.NoError(t, err)
+
+	inventory, err := InventoryTemplates(helmChart)
+	require.NoError(t, err)
+
+	paths := []string{}
+	for _, v := range inventory.Values {
+		paths = append(paths, v.Path)
+	}
+	assert.Equal(t, []string{
+		"values.hostname",
+		"values.hosts[1]",
+		"values.image.registry",
+		"values.seats",
+		"optionalValues[0].when",
+		"optionalValues[0].values.tls.cert",
+		"optionalValues[1].when",
+		"exclude",
+	}, paths)
+
+	assert.Equal(t, []string{"HasLocalRegistry", "LocalRegistryHost"}, inventory.Values[2].Functions)
+	assert.Equal(t, []string{"seats"}, inventory.Values[3].LicenseFields)
+	assert.Error(t, inventory.Values[6].ParseError)
+
+	assert.Equal(t, []string{"deploy_app", "extra_host", "hostname", "tls", "tls_cert"}, inventory.ConfigItems())
+	assert.Equal(t, []string{"seats"}, inventory.LicenseFields())
+	assert.Equal(t, []string{
+		"ConfigOption",
+		"ConfigOptionData",
+		"ConfigOptionEquals",
+		"HasLocalRegistry",
+		"LicenseFieldValue",
+		"LocalRegistryHost",
+	}, inventory.Functions())
+}
+
+func TestInventoryTemplates_V1beta1(t *testing.T) {
+	helmChart, err := Decode([]byte(v1beta1HelmChartYAML))
+	require.NoError(t, err)
+
+	inventory, err := InventoryTemplates(helmChart)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, inventory.ConfigItems())
+}
@@ -0,0 +1,94 @@
+package helmchart
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ValidationError describes a single value that does not conform to a chart's
+// values.schema.json.
+type ValidationError struct {
+	// Source is "values" for spec.values, or "optionalValues[N]" when the error only
+	// occurs once the Nth optional value is applied.
+	Source string
+	// Path is the location of the value, e.g. "ingress.hosts[0].port". It is empty
+	// for the root of the values tree.
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %s", e.Source, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Source, e.Path, e.Message)
+}
+
+// ValidateValues checks the values of a HelmChart against the chart's values.schema.json.
+//
+// defaultValues holds the chart's own values.yaml and may be nil. As in Helm, the
+// HelmChart values are merged on top of the defaults before validation, so a required
+// value provided by the chart is not reported as missing.
+//
+// spec.values are always validated. Whether an optional value applies depends on its
+// `when` template, which cannot be evaluated here, so each entry in spec.optionalValues
+// is merged onto spec.values on its own and any additional errors it introduces are
+// reported against it. Optional values provided as a templated string are skipped.
+//
+// String values containing repl templates are treated as wildcards that satisfy any
+// schema, since their rendered type is not known until install time.
+//
+// The schema is evaluated with github.com/santhosh-tekuri/jsonschema. The draft is taken
+// from $schema and defaults to draft-07. Only references within the schema itself can be
+// resolved.
+func ValidateValues(helmChart HelmChartInterface, valuesSchema []byte, defaultValues map[string]interface{}) ([]ValidationError, error) {
+	schema, err := compileValuesSchema(valuesSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := renderSpec(helmChart)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get helm chart values")
+	}
+
+	baseValues := mergeValues(defaultValues, spec.values, true)
+
+	baseErrors, err := validateAgainstSchema(schema, baseValues)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []ValidationError{}
+	seen := map[string]bool{}
+	for _, schemaErr := range baseErrors {
+		seen[schemaErr.path+"\x00"+schemaErr.message] = true
+		result = append(result, ValidationError{Source: "values", Path: schemaErr.path, Message: schemaErr.message})
+	}
+
+	for _, optionalValue := range spec.optionalValues {
+		if optionalValue.values == nil {
+			continue
+		}
+
+		merged := mergeValues(baseValues, optionalValue.values, optionalValue.recursiveMerge)
+		optionalErrors, err := validateAgainstSchema(schema, merged)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, schemaErr := range optionalErrors {
+			if seen[schemaErr.path+"\x00"+schemaErr.message] {
+				continue
+			}
+			result = append(result, ValidationError{
+				Source:  fmt.Sprintf("optionalValues[%d]", optionalValue.index),
+				Path:    schemaErr.path,
+				Message: schemaErr.message,
+			})
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,270 @@
+package helmchart
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testValuesSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["image"],
+  "properties": {
+    "replicaCount": {"type": "integer", "minimum": 1},
+    "image": {
+      "type": "object",
+      "required": ["repository"],
+      "properties": {
+        "repository": {"type": "string", "minLength": 1},
+        "pullPolicy": {"enum": ["Always", "IfNotPresent", "Never"]}
+      }
+    },
+    "service": {"$ref": "#/definitions/service"},
+    "ingress": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "enabled": {"type": "boolean"},
+        "hosts": {"type": "array", "items": {"type": "string", "pattern": "^[a-z0-9.-]+$"}}
+      }
+    }
+  },
+  "definitions": {
+    "service": {
+      "type": "object",
+      "properties": {
+        "port": {"type": "integer", "minimum": 1, "maximum": 65535}
+      }
+    }
+  }
+}`
+
+func TestValidateValues(t *testing.T) {
+	tests := []struct {
+		name          string
+		helmChart     string
+		defaultValues map[string]interface{}
+		want          []ValidationError
+	}{
+		{
+			name: "valid values",
+			helmChart: `apiVersion: kots.io/v1beta2
+kind: HelmChart
+metadata:
+  name: app
+spec:
+  chart:
+    name: app
+    chartVersion: 1.0.0
+  values:
+    replicaCount: 2
+    image:
+      repository: nginx
+      pullPolicy: Always
+    service:
+      port: 8080
+`,
+			want: []ValidationError{},
+		},
+		{
+			name: "repl templates are wildcards",
+			helmChart: `apiVersion: kots.io/v1beta1
+kind: HelmChart
+metadata:
+  name: app
+spec:
+  chart:
+    name: app
+    chartVersion: 1.0.0
+  values:
+    replicaCount: repl{{ ConfigOption "replicas" }}
+    image:
+      repository: '{{repl LocalRegistryAddress }}/nginx'
+    ingress:
+      enabled: repl{{ ConfigOptionEquals "ingress" "1" }}
+`,
+			want: []ValidationError{},
+		},
+		{
+			name: "type and constraint errors",
+			helmChart: `apiVersion: kots.io/v1beta2
+kind: HelmChart
+metadata:
+  name: app
+spec:
+  chart:
+    name: app
+    chartVersion: 1.0.0
+  values:
+    replicaCount: "two"
+    image:
+      repository: nginx
+      pullPolicy: Sometimes
+    service:
+      port: 70000
+    ingress:
+      enabled: true
+      hosts: ["Example.com"]
+      tls: true
+`,
+			want: []ValidationError{
+				{Source: "values", Path: "image.pullPolicy", Message: "value must be one of 'Always', 'IfNotPresent', 'Never'"},
+				{Source: "values", Path: "ingress", Message: "additional properties 'tls' not allowed"},
+				{Source: "values", Path: "ingress.hosts[0]", Message: "'Example.com' does not match pattern '^[a-z0-9.-]+$'"},
+				{Source: "values", Path: "replicaCount", Message: "got string, want integer"},
+				{Source: "values", Path: "service.port", Message: "maximum: got 70,000, want 65,535"},
+			},
+		},
+		{
+			name: "missing required value",
+			helmChart: `apiVersion: kots.io/v1beta2
+kind: HelmChart
+metadata:
+  name: app
+spec:
+  chart:
+    name: app
+    chartVersion: 1.0.0
+  values:
+    replicaCount: 1
+`,
+			want: []ValidationError{
+				{Source: "values", Path: "", Message: "missing property 'image'"},
+			},
+		},
+		{
+			name: "required value provided by chart defaults",
+			helmChart: `apiVersion: kots.io/v1beta2
+kind: HelmChart
+metadata:
+  name: app
+spec:
+  chart:
+    name: app
+    chartVersion: 1.0.0
+  values:
+    replicaCount: 1
+`,
+			defaultValues: map[string]interface{}{
+				"image": map[string]interface{}{"repository": "nginx"},
+			},
+			want: []ValidationError{},
+		},
+		{
+			name: "optional values are validated separately",
+			helmChart: `apiVersion: kots.io/v1beta2
+kind: HelmChart
+metadata:
+  name: app
+spec:
+  chart:
+    name: app
+    chartVersion: 1.0.0
+  values:
+    image:
+      repository: nginx
+  optionalValues:
+  - when: repl{{ ConfigOptionEquals "mode" "ha" }}
+    values:
+      replicaCount: 0
+  - when: repl{{ ConfigOptionEquals "mode" "custom" }}
+    values: repl{{ ConfigOption "custom_values" | nindent 6 }}
+  - when: "true"
+    recursiveMerge: true
+    values:
+      image:
+        pullPolicy: Sometimes
+`,
+			want: []ValidationError{
+				{Source: "optionalValues[0]", Path: "replicaCount", Message: "minimum: got 0, want 1"},
+				{Source: "optionalValues[2]", Path: "image.pullPolicy", Message: "value must be one of 'Always', 'IfNotPresent', 'Never'"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helmChart, err := Decode([]byte(tt.helmChart))
+			require.NoError(t, err)
+
+			got, err := ValidateValues(helmChart, []byte(testValuesSchema), tt.defaultValues)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateValues_InvalidSchema(t *testing.T) {
+	helmChart, err := Decode([]byte(v1beta2HelmChartYAML))
+	require.NoError(t, err)
+
+	_, err = ValidateValues(helmChart, []byte(`{"type": "object"`), nil)
+	assert.Error(t, err)
+
+	_, err = ValidateValues(helmChart, []byte(`{"$ref": "https://example.com/schema.json"}`), nil)
+	assert.Error(t, err)
+
+	_, err = ValidateValues(helmChart, []byte(`{"properties": {"name": {"$ref": "#/definitions/missing"}}}`), map[string]interface{}{"name": "x"})
+	assert.Error(t, err)
+}
+
+func TestValidateValues_Combinators(t *testing.T) {
+	valuesSchema := `{
+  "type": "object",
+  "properties": {
+    "port": {"anyOf": [{"type": "integer"}, {"type": "string", "pattern": "^[0-9]+$"}]},
+    "size": {"oneOf": [{"type": "integer", "maximum": 9007199254740992}, {"type": "boolean"}]}
+  }
+}`
+
+	tests := []struct {
+		name   string
+		values string
+		want   []ValidationError
+	}{
+		{
+			name: "templated value satisfies anyOf",
+			values: `    port: repl{{ ConfigOption "port" }}
+`,
+			want: []ValidationError{},
+		},
+		{
+			name: "no subschema matches",
+			values: `    port: http
+`,
+			want: []ValidationError{
+				{Source: "values", Path: "port", Message: "'anyOf' failed"},
+			},
+		},
+		{
+			name: "large integers keep their precision",
+			values: `    size: 9007199254740993
+`,
+			want: []ValidationError{
+				{Source: "values", Path: "size", Message: "'oneOf' failed, none matched"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helmChart, err := Decode([]byte(`apiVersion: kots.io/v1beta2
+kind: HelmChart
+metadata:
+  name: app
+spec:
+  chart:
+    name: app
+    chartVersion: 1.0.0
+  values:
+` + tt.values))
+			require.NoError(t, err)
+
+			got, err := ValidateValues(helmChart, []byte(valuesSchema), nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
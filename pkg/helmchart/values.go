@@ -0,0 +1,125 @@
+package helmchart
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	kotsv1beta2 "github.com/replicatedhq/kotskinds/apis/kots/v1beta2"
+	"github.com/replicatedhq/kotskinds/multitype"
+)
+
+// renderedSpec holds the parts of a HelmChart spec that carry helm values or repl templates,
+// with values rendered to plain Go types. Repl templates are left in place as strings.
+type renderedSpec struct {
+	values         map[string]interface{}
+	optionalValues []renderedOptionalValue
+	// exclude is the string form of spec.exclude, empty when it is a boolean
+	exclude string
+}
+
+// renderedOptionalValue is an optionalValues entry with its values rendered to plain Go types
+type renderedOptionalValue struct {
+	// index is the position of the entry in spec.optionalValues
+	index          int
+	when           string
+	recursiveMerge bool
+	// values is nil when the optional value was provided as a templated string
+	values       map[string]interface{}
+	valuesString string
+}
+
+// renderSpec returns the values, optional values and exclude of a v1beta1 or v1beta2 HelmChart
+func renderSpec(helmChart HelmChartInterface) (*renderedSpec, error) {
+	switch h := helmChart.(type) {
+	case *kotsv1beta1.HelmChart:
+		values, err := h.Spec.GetHelmValues(h.Spec.Values)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render values")
+		}
+		rendered := &renderedSpec{values: values, optionalValues: []renderedOptionalValue{}}
+		if h.Spec.Exclude.Type == multitype.String {
+			rendered.exclude = h.Spec.Exclude.StrVal
+		}
+		for i, optionalValue := range h.Spec.OptionalValues {
+			if optionalValue == nil {
+				continue
+			}
+			renderedOptional := renderedOptionalValue{
+				index:          i,
+				when:           optionalValue.When,
+				recursiveMerge: optionalValue.RecursiveMerge,
+				valuesString:   optionalValue.ValuesString(),
+			}
+			if optionalValue.Values != nil {
+				renderedOptional.values, err = h.Spec.GetHelmValues(optionalValue.Values)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to render optional values %d", i)
+				}
+			}
+			rendered.optionalValues = append(rendered.optionalValues, renderedOptional)
+		}
+		return rendered, nil
+
+	case *kotsv1beta2.HelmChart:
+		values, err := h.Spec.GetHelmValues(h.Spec.Values)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render values")
+		}
+		rendered := &renderedSpec{values: values, optionalValues: []renderedOptionalValue{}}
+		if h.Spec.Exclude.Type == multitype.String {
+			rendered.exclude = h.Spec.Exclude.StrVal
+		}
+		for i, optionalValue := range h.Spec.OptionalValues {
+			if optionalValue == nil {
+				continue
+			}
+			renderedOptional := renderedOptionalValue{
+				index:          i,
+				when:           optionalValue.When,
+				recursiveMerge: optionalValue.RecursiveMerge,
+				valuesString:   optionalValue.ValuesString(),
+			}
+			if optionalValue.Values != nil {
+				renderedOptional.values, err = h.Spec.GetHelmValues(optionalValue.Values)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to render optional values %d", i)
+				}
+			}
+			rendered.optionalValues = append(rendered.optionalValues, renderedOptional)
+		}
+		return rendered, nil
+
+	default:
+		return nil, errors.Errorf("unsupported helm chart type %T", helmChart)
+	}
+}
+
+// isReplTemplate reports whether a value contains a repl template, using the same
+// markers as HelmChartSpec.GetReplTmplValues.
+func isReplTemplate(value interface{}) bool {
+	str, ok := value.(string)
+	return ok && (strings.Contains(str, "repl{{") || strings.Contains(str, "{{repl"))
+}
+
+// mergeValues merges overlay onto base and returns the result. Nested maps are merged
+// recursively when recursive is true, otherwise top level keys in overlay replace
+// those in base. Neither input is modified.
+func mergeValues(base, overlay map[string]interface{}, recursive bool) map[string]interface{} {
+	result := make(map[string]interface{}, len(base)+len(overlay))
+	for k, v := range base {
+		result[k] = v
+	}
+
+	for k, v := range overlay {
+		baseMap, baseIsMap := result[k].(map[string]interface{})
+		overlayMap, overlayIsMap := v.(map[string]interface{})
+		if recursive && baseIsMap && overlayIsMap {
+			result[k] = mergeValues(baseMap, overlayMap, true)
+			continue
+		}
+		result[k] = v
+	}
+
+	return result
+}
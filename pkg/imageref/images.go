@@ -0,0 +1,93 @@
+package imageref
+
+import (
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+)
+
+// EffectiveImages returns the images in known and additional that are not excluded, without
+// duplicates, in the order they are first listed. An excluded reference without a tag or
+// digest excludes every tag of its repository, otherwise only the same image is excluded.
+func EffectiveImages(known []string, additional []string, excluded []string) ([]Reference, error) {
+	excludedRefs, err := ParseAll(excluded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse excluded images")
+	}
+
+	images := []Reference{}
+	for _, image := range append(append([]string{}, known...), additional...) {
+		ref, err := Parse(image)
+		if err != nil {
+			return nil, err
+		}
+		if isExcluded(ref, excludedRefs) || containsReference(images, ref) {
+			continue
+		}
+		images = append(images, ref)
+	}
+
+	return images, nil
+}
+
+// ApplicationImages returns the effective images of an installed app: the installation's
+// known images and the application's additional images, less its excluded images. Either
+// argument may be nil.
+func ApplicationImages(application *kotsv1beta1.Application, installation *kotsv1beta1.Installation) ([]Reference, error) {
+	known := []string{}
+	if installation != nil {
+		for _, image := range installation.Spec.KnownImages {
+			known = append(known, image.Image)
+		}
+	}
+
+	var additional, excluded []string
+	if application != nil {
+		additional = application.Spec.AdditionalImages
+		excluded = application.Spec.ExcludedImages
+	}
+
+	return EffectiveImages(known, additional, excluded)
+}
+
+// AirgapImages returns the images saved in an airgap bundle, including those saved in the
+// embedded cluster registry
+func AirgapImages(airgap *kotsv1beta1.Airgap) ([]Reference, error) {
+	images := append([]string{}, airgap.Spec.SavedImages...)
+	if artifacts := airgap.Spec.EmbeddedClusterArtifacts; artifacts != nil {
+		images = append(images, artifacts.Registry.SavedImages...)
+	}
+	return EffectiveImages(images, nil, nil)
+}
+
+// NotDigestPinned returns the references in refs that don't include a digest
+func NotDigestPinned(refs []Reference) []Reference {
+	unpinned := []Reference{}
+	for _, ref := range refs {
+		if !ref.IsDigestPinned() {
+			unpinned = append(unpinned, ref)
+		}
+	}
+	return unpinned
+}
+
+func isExcluded(ref Reference, excluded []Reference) bool {
+	for _, e := range excluded {
+		if e.Tag == "" && e.Digest == "" {
+			if e.Name() == ref.Name() {
+				return true
+			}
+		} else if e.Equal(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsReference(refs []Reference, ref Reference) bool {
+	for _, r := range refs {
+		if r.Equal(ref) {
+			return true
+		}
+	}
+	return false
+}
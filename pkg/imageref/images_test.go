@@ -0,0 +1,80 @@
+package imageref
+
+import (
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func referenceStrings(refs []Reference) []string {
+	strs := []string{}
+	for _, ref := range refs {
+		strs = append(strs, ref.String())
+	}
+	return strs
+}
+
+func TestEffectiveImages(t *testing.T) {
+	images, err := EffectiveImages(
+		[]string{"nginx:1.25", "redis", "quay.io/my-org/api:1.0", "quay.io/my-org/api:1.1"},
+		[]string{"docker.io/library/nginx:1.25", "busybox", "redis:latest"},
+		[]string{"quay.io/my-org/api", "busybox:1.36"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"docker.io/library/nginx:1.25",
+		"docker.io/library/redis",
+		"docker.io/library/busybox",
+	}, referenceStrings(images))
+
+	_, err = EffectiveImages([]string{"nginx"}, nil, []string{"Invalid:"})
+	require.Error(t, err)
+}
+
+func TestApplicationImages(t *testing.T) {
+	application := &kotsv1beta1.Application{
+		Spec: kotsv1beta1.ApplicationSpec{
+			AdditionalImages: []string{"busybox:1.36"},
+			ExcludedImages:   []string{"redis"},
+		},
+	}
+	installation := &kotsv1beta1.Installation{
+		Spec: kotsv1beta1.InstallationSpec{
+			KnownImages: []kotsv1beta1.InstallationImage{
+				{Image: "nginx@" + testDigest},
+				{Image: "redis:7", IsPrivate: true},
+			},
+		},
+	}
+
+	images, err := ApplicationImages(application, installation)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"docker.io/library/nginx@" + testDigest,
+		"docker.io/library/busybox:1.36",
+	}, referenceStrings(images))
+	assert.Equal(t, []string{"docker.io/library/busybox:1.36"}, referenceStrings(NotDigestPinned(images)))
+
+	images, err = ApplicationImages(nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, images)
+}
+
+func TestAirgapImages(t *testing.T) {
+	airgap := &kotsv1beta1.Airgap{
+		Spec: kotsv1beta1.AirgapSpec{
+			SavedImages: []string{"nginx:1.25"},
+			EmbeddedClusterArtifacts: &kotsv1beta1.EmbeddedClusterArtifacts{
+				Registry: kotsv1beta1.EmbeddedClusterRegistry{
+					SavedImages: []string{"registry.k8s.io/pause:3.9", "nginx:1.25"},
+				},
+			},
+		},
+	}
+
+	images, err := AirgapImages(airgap)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docker.io/library/nginx:1.25", "registry.k8s.io/pause:3.9"}, referenceStrings(images))
+}
@@ -0,0 +1,143 @@
+// Package imageref parses the image references held as strings in Application,
+// Installation and Airgap specs, and rewrites them for the Replicated registry, the
+// Replicated proxy registry or a local registry.
+package imageref
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DockerHubRegistry is the registry of references that don't name one
+	DockerHubRegistry = "docker.io"
+	// DefaultTag is the tag of references that have neither a tag nor a digest
+	DefaultTag = "latest"
+)
+
+var (
+	repositoryComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	tagRegexp                 = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRegexp              = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]{32,}$`)
+	registryRegexp            = regexp.MustCompile(`^[a-zA-Z0-9.-]+(?::[0-9]+)?$`)
+)
+
+// Reference is a parsed image reference. Tag is empty when the reference has no tag, and
+// Digest is empty when it is not pinned to a digest.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// Parse parses image into a Reference. References without a registry are on docker.io, and
+// single component docker.io repositories are in library, so "nginx" is parsed as
+// "docker.io/library/nginx".
+func Parse(image string) (Reference, error) {
+	ref := Reference{}
+
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+		if !digestRegexp.MatchString(ref.Digest) {
+			return Reference{}, errors.Errorf("invalid digest %q in %q", ref.Digest, image)
+		}
+	}
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+		if !tagRegexp.MatchString(ref.Tag) {
+			return Reference{}, errors.Errorf("invalid tag %q in %q", ref.Tag, image)
+		}
+	}
+
+	ref.Registry = DockerHubRegistry
+	if domain, rest, ok := strings.Cut(name, "/"); ok && isRegistry(domain) {
+		if !registryRegexp.MatchString(domain) {
+			return Reference{}, errors.Errorf("invalid registry %q in %q", domain, image)
+		}
+		ref.Registry, name = domain, rest
+	}
+	if ref.Registry == "index.docker.io" {
+		ref.Registry = DockerHubRegistry
+	}
+
+	if name == "" {
+		return Reference{}, errors.Errorf("missing repository in %q", image)
+	}
+	for _, component := range strings.Split(name, "/") {
+		if !repositoryComponentRegexp.MatchString(component) {
+			return Reference{}, errors.Errorf("invalid repository %q in %q", name, image)
+		}
+	}
+	if ref.Registry == DockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+
+	return ref, nil
+}
+
+// ParseAll parses every image in images
+func ParseAll(images []string) ([]Reference, error) {
+	refs := make([]Reference, 0, len(images))
+	for _, image := range images {
+		ref, err := Parse(image)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// isRegistry returns true if the first component of a reference is a registry rather than
+// part of the repository
+func isRegistry(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost" || strings.ToLower(component) != component
+}
+
+// Name returns the registry and repository of the reference
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the full reference, including the registry
+func (r Reference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// IsDigestPinned returns true if the reference includes a digest, and so always resolves to
+// the same image
+func (r Reference) IsDigestPinned() bool {
+	return r.Digest != ""
+}
+
+// Equal returns true if r and other resolve to the same image, treating references without
+// a tag or digest as tagged latest
+func (r Reference) Equal(other Reference) bool {
+	return r.normalized() == other.normalized()
+}
+
+func (r Reference) normalized() Reference {
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = DefaultTag
+	}
+	return r
+}
+
+// Path returns the last component of the repository, such as "nginx" for
+// "docker.io/library/nginx"
+func (r Reference) Path() string {
+	return r.Repository[strings.LastIndex(r.Repository, "/")+1:]
+}
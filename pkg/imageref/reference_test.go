@@ -0,0 +1,88 @@
+package imageref
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:2e863c44b718727c860746568e1d54afd13b2fa71b160f5cd9058fc436217b30"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		image  string
+		want   Reference
+		string string
+	}{
+		{
+			image:  "nginx",
+			want:   Reference{Registry: "docker.io", Repository: "library/nginx"},
+			string: "docker.io/library/nginx",
+		},
+		{
+			image:  "nginx:1.25",
+			want:   Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.25"},
+			string: "docker.io/library/nginx:1.25",
+		},
+		{
+			image:  "index.docker.io/bitnami/redis:7",
+			want:   Reference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7"},
+			string: "docker.io/bitnami/redis:7",
+		},
+		{
+			image:  "registry.replicated.com/my-app/api@" + testDigest,
+			want:   Reference{Registry: "registry.replicated.com", Repository: "my-app/api", Digest: testDigest},
+			string: "registry.replicated.com/my-app/api@" + testDigest,
+		},
+		{
+			image:  "localhost:5000/team/api:v1.0.0@" + testDigest,
+			want:   Reference{Registry: "localhost:5000", Repository: "team/api", Tag: "v1.0.0", Digest: testDigest},
+			string: "localhost:5000/team/api:v1.0.0@" + testDigest,
+		},
+		{
+			image:  "quay.io/prometheus/node-exporter:v1.8.0",
+			want:   Reference{Registry: "quay.io", Repository: "prometheus/node-exporter", Tag: "v1.8.0"},
+			string: "quay.io/prometheus/node-exporter:v1.8.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			ref, err := Parse(tt.image)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ref)
+			assert.Equal(t, tt.string, ref.String())
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, image := range []string{
+		"",
+		"nginx:",
+		"nginx@sha256:abc",
+		"Nginx/Api",
+		"nginx:bad tag",
+		"registry.example.com/",
+		"my_registry.example.com/api",
+	} {
+		_, err := Parse(image)
+		assert.Error(t, err, image)
+	}
+}
+
+func TestReferenceEqual(t *testing.T) {
+	nginx, err := Parse("nginx")
+	require.NoError(t, err)
+	latest, err := Parse("docker.io/library/nginx:latest")
+	require.NoError(t, err)
+	pinned, err := Parse("nginx@" + testDigest)
+	require.NoError(t, err)
+
+	assert.True(t, nginx.Equal(latest))
+	assert.False(t, nginx.Equal(pinned))
+	assert.False(t, nginx.IsDigestPinned())
+	assert.True(t, pinned.IsDigestPinned())
+	assert.Equal(t, "nginx", nginx.Path())
+}
@@ -0,0 +1,92 @@
+package imageref
+
+import (
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+)
+
+const (
+	// DefaultReplicatedRegistryDomain hosts images pushed to the Replicated registry
+	DefaultReplicatedRegistryDomain = "registry.replicated.com"
+	// DefaultProxyRegistryDomain proxies private images from other registries
+	DefaultProxyRegistryDomain = "proxy.replicated.com"
+)
+
+// Rewriter rewrites image references to be pulled through the registries of an app
+type Rewriter struct {
+	AppSlug string
+	// ReplicatedRegistryDomain replaces registry.replicated.com in references
+	ReplicatedRegistryDomain string
+	// ProxyRegistryDomain is the proxy registry used for private images
+	ProxyRegistryDomain string
+	// ProxyPublicImages sends public images through the proxy registry too
+	ProxyPublicImages bool
+}
+
+// NewRewriter returns a Rewriter using the registry domains of application, or the
+// Replicated defaults when it does not set them. application may be nil.
+func NewRewriter(application *kotsv1beta1.Application, appSlug string) *Rewriter {
+	rewriter := &Rewriter{
+		AppSlug:                  appSlug,
+		ReplicatedRegistryDomain: DefaultReplicatedRegistryDomain,
+		ProxyRegistryDomain:      DefaultProxyRegistryDomain,
+	}
+	if application == nil {
+		return rewriter
+	}
+	if application.Spec.ReplicatedRegistryDomain != "" {
+		rewriter.ReplicatedRegistryDomain = application.Spec.ReplicatedRegistryDomain
+	}
+	if application.Spec.ProxyRegistryDomain != "" {
+		rewriter.ProxyRegistryDomain = application.Spec.ProxyRegistryDomain
+	}
+	rewriter.ProxyPublicImages = application.Spec.ProxyPublicImages
+	return rewriter
+}
+
+// Rewrite returns ref as it should be pulled. Images in the Replicated registry use
+// ReplicatedRegistryDomain. Other private images, and public images when ProxyPublicImages
+// is set, are pulled through the proxy registry. Other images are unchanged.
+func (r *Rewriter) Rewrite(ref Reference, isPrivate bool) Reference {
+	if ref.Registry == DefaultReplicatedRegistryDomain || ref.Registry == r.ReplicatedRegistryDomain {
+		return RewriteReplicatedRegistry(ref, r.ReplicatedRegistryDomain)
+	}
+	if ref.Registry == r.ProxyRegistryDomain {
+		return ref
+	}
+	if isPrivate || r.ProxyPublicImages {
+		return RewriteProxyRegistry(ref, r.ProxyRegistryDomain, r.AppSlug)
+	}
+	return ref
+}
+
+// RewriteReplicatedRegistry returns ref with the registry replaced by domain
+func RewriteReplicatedRegistry(ref Reference, domain string) Reference {
+	if domain == "" {
+		domain = DefaultReplicatedRegistryDomain
+	}
+	ref.Registry = domain
+	return ref
+}
+
+// RewriteProxyRegistry returns ref pulled through the proxy registry at domain, as
+// <domain>/proxy/<appSlug>/<registry>/<repository>
+func RewriteProxyRegistry(ref Reference, domain string, appSlug string) Reference {
+	if domain == "" {
+		domain = DefaultProxyRegistryDomain
+	}
+	ref.Repository = "proxy/" + appSlug + "/" + ref.Registry + "/" + ref.Repository
+	ref.Registry = domain
+	return ref
+}
+
+// RewriteLocalRegistry returns ref pushed to a local registry at host, as
+// <host>/<namespace>/<path>, where path is the last component of the repository. namespace
+// may be empty.
+func RewriteLocalRegistry(ref Reference, host string, namespace string) Reference {
+	ref.Repository = ref.Path()
+	if namespace != "" {
+		ref.Repository = namespace + "/" + ref.Repository
+	}
+	ref.Registry = host
+	return ref
+}
@@ -0,0 +1,82 @@
+package imageref
+
+import (
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, image string) Reference {
+	t.Helper()
+	ref, err := Parse(image)
+	require.NoError(t, err)
+	return ref
+}
+
+func TestRewriter(t *testing.T) {
+	application := &kotsv1beta1.Application{
+		Spec: kotsv1beta1.ApplicationSpec{
+			ReplicatedRegistryDomain: "registry.example.com",
+			ProxyRegistryDomain:      "proxy.example.com",
+		},
+	}
+
+	tests := []struct {
+		name        string
+		application *kotsv1beta1.Application
+		image       string
+		isPrivate   bool
+		want        string
+	}{
+		{
+			name:        "replicated registry",
+			application: application,
+			image:       "registry.replicated.com/my-app/api:1.0",
+			isPrivate:   true,
+			want:        "registry.example.com/my-app/api:1.0",
+		},
+		{
+			name:        "private image is proxied",
+			application: application,
+			image:       "quay.io/my-org/api:1.0",
+			isPrivate:   true,
+			want:        "proxy.example.com/proxy/my-app/quay.io/my-org/api:1.0",
+		},
+		{
+			name:        "public image is unchanged",
+			application: application,
+			image:       "nginx:1.25",
+			want:        "docker.io/library/nginx:1.25",
+		},
+		{
+			name:        "already proxied",
+			application: application,
+			image:       "proxy.example.com/proxy/my-app/quay.io/my-org/api:1.0",
+			isPrivate:   true,
+			want:        "proxy.example.com/proxy/my-app/quay.io/my-org/api:1.0",
+		},
+		{
+			name: "public images proxied by default domain",
+			application: &kotsv1beta1.Application{
+				Spec: kotsv1beta1.ApplicationSpec{ProxyPublicImages: true},
+			},
+			image: "nginx@" + testDigest,
+			want:  "proxy.replicated.com/proxy/my-app/docker.io/library/nginx@" + testDigest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rewriter := NewRewriter(tt.application, "my-app")
+			assert.Equal(t, tt.want, rewriter.Rewrite(mustParse(t, tt.image), tt.isPrivate).String())
+		})
+	}
+}
+
+func TestRewriteLocalRegistry(t *testing.T) {
+	ref := mustParse(t, "quay.io/my-org/api:1.0")
+	assert.Equal(t, "10.96.0.10:5000/my-app/api:1.0", RewriteLocalRegistry(ref, "10.96.0.10:5000", "my-app").String())
+	assert.Equal(t, "registry.local/api:1.0", RewriteLocalRegistry(ref, "registry.local", "").String())
+}
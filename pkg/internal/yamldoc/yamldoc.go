@@ -0,0 +1,183 @@
+// Package yamldoc splits multi-document YAML streams, such as the files of a release, into
+// their documents.
+package yamldoc
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml "go.yaml.in/yaml/v3"
+)
+
+var errorLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// Document is a document in a multi-document YAML stream
+type Document struct {
+	// Index is the zero-based index of the document in the stream. Empty documents are not
+	// counted.
+	Index int
+	// Line is the one-based line of the stream at which the document starts, after its
+	// "---" marker
+	Line int
+	// Data is the source of the document, from Line up to the next document
+	Data []byte
+	// Node is the decoded document, with line numbers relative to the stream. It is nil
+	// when the document is not valid YAML.
+	Node *yaml.Node
+	// Err is the error decoding the document, with line numbers relative to Data
+	Err error
+}
+
+// Split returns the documents in data. Documents that are empty or contain only whitespace
+// are dropped and do not count towards the document index.
+//
+// Document boundaries and positions are taken from the yaml.v3 decoder, so a "---" that is
+// part of a scalar does not start a new document. A document that is not valid YAML stops
+// the decoder; it is taken to run up to the next "---" marker at the start of a line, which
+// the YAML spec does not allow inside a document, and decoding resumes there.
+func Split(data []byte) []Document {
+	lines := strings.SplitAfter(string(data), "\n")
+
+	documents := []Document{}
+	add := func(start int, end int, node *yaml.Node, err error) {
+		line := start
+		if isBareMarker(lines[start-1]) {
+			line++
+		}
+		content := strings.Join(lines[min(line, end)-1:end-1], "")
+		if strings.TrimSpace(content) == "" {
+			return
+		}
+		documents = append(documents, Document{
+			Index: len(documents),
+			Line:  line,
+			Data:  []byte(content),
+			Node:  node,
+			Err:   err,
+		})
+	}
+
+	// offset is the number of lines before the part of the stream being decoded
+	offset := 0
+	for offset < len(lines) {
+		decoder := yaml.NewDecoder(strings.NewReader(strings.Join(lines[offset:], "")))
+
+		starts := []int{}
+		nodes := []*yaml.Node{}
+		var decodeErr error
+		for {
+			node := &yaml.Node{}
+			if err := decoder.Decode(node); err == io.EOF {
+				break
+			} else if err != nil {
+				decodeErr = err
+				break
+			}
+			shiftLines(node, offset)
+
+			start := node.Line
+			if len(nodes) == 0 {
+				// comments before the first document belong to it
+				start = offset + 1
+			}
+			starts = append(starts, start)
+			nodes = append(nodes, node)
+		}
+
+		if decodeErr == nil {
+			for i, node := range nodes {
+				end := len(lines) + 1
+				if i+1 < len(starts) {
+					end = starts[i+1]
+				}
+				add(starts[i], end, node, nil)
+			}
+			return documents
+		}
+
+		// the document that failed starts at the first marker after the last document that
+		// was decoded, and ends at the next one
+		brokenStart := offset + 1
+		if len(starts) > 0 {
+			last := starts[len(starts)-1]
+			fallback := min(max(errorLine(decodeErr, offset), last+1), len(lines))
+			brokenStart = nextMarker(lines, last, fallback)
+		}
+		brokenEnd := nextMarker(lines, brokenStart, len(lines)+1)
+
+		for i, node := range nodes {
+			end := brokenStart
+			if i+1 < len(starts) {
+				end = starts[i+1]
+			}
+			add(starts[i], end, node, nil)
+		}
+
+		line := brokenStart
+		if isBareMarker(lines[brokenStart-1]) {
+			line++
+		}
+		var node yaml.Node
+		err := yaml.Unmarshal([]byte(strings.Join(lines[min(line, brokenEnd)-1:brokenEnd-1], "")), &node)
+		if err == nil {
+			// the document is only invalid as part of the stream
+			err = decodeErr
+		}
+		add(brokenStart, brokenEnd, nil, err)
+
+		offset = brokenEnd - 1
+	}
+
+	return documents
+}
+
+// nextMarker returns the line of the first document marker after line after, or fallback
+// when there is none
+func nextMarker(lines []string, after int, fallback int) int {
+	for i := after; i < len(lines); i++ {
+		if isMarker(lines[i]) {
+			return i + 1
+		}
+	}
+	return fallback
+}
+
+// errorLine returns the line of the stream that err is about, or zero when err does not say
+func errorLine(err error, offset int) int {
+	match := errorLineRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(match[1])
+	return line + offset
+}
+
+// isMarker returns true if line is a "---" document marker, which may be followed by
+// content
+func isMarker(line string) bool {
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "---") {
+		return false
+	}
+	rest := line[3:]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
+
+// isBareMarker returns true if line is a "---" document marker with nothing after it but a
+// comment
+func isBareMarker(line string) bool {
+	if !isMarker(line) {
+		return false
+	}
+	rest := strings.TrimSpace(strings.TrimRight(line, "\r\n")[3:])
+	return rest == "" || strings.HasPrefix(rest, "#")
+}
+
+func shiftLines(node *yaml.Node, offset int) {
+	node.Line += offset
+	for _, child := range node.Content {
+		shiftLines(child, offset)
+	}
+}
@@ -0,0 +1,63 @@
+package yamldoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	stream := `# leading comment
+a: 1
+---
+script: |
+  echo start
+  ---
+  echo end
+---
+
+---
+# comment only
+--- # broken
+b: [
+---
+c: 1
+`
+
+	documents := Split([]byte(stream))
+	require.Len(t, documents, 5)
+
+	assert.Equal(t, 0, documents[0].Index)
+	assert.Equal(t, 1, documents[0].Line)
+	assert.Equal(t, "# leading comment\na: 1\n", string(documents[0].Data))
+	assert.Equal(t, 2, documents[0].Node.Content[0].Line)
+
+	// the "---" in the block scalar does not start a document
+	assert.Equal(t, 1, documents[1].Index)
+	assert.Equal(t, 4, documents[1].Line)
+	assert.Equal(t, "script: |\n  echo start\n  ---\n  echo end\n", string(documents[1].Data))
+	assert.Equal(t, "echo start\n---\necho end\n", documents[1].Node.Content[0].Content[1].Value)
+
+	// the empty document is dropped, the comment only document is not
+	assert.Equal(t, 2, documents[2].Index)
+	assert.Equal(t, 11, documents[2].Line)
+	assert.NoError(t, documents[2].Err)
+
+	assert.Equal(t, 3, documents[3].Index)
+	assert.Equal(t, 13, documents[3].Line)
+	assert.Equal(t, "b: [\n", string(documents[3].Data))
+	assert.Nil(t, documents[3].Node)
+	assert.Error(t, documents[3].Err)
+
+	// decoding resumes after the broken document, with positions relative to the stream
+	assert.Equal(t, 4, documents[4].Index)
+	assert.Equal(t, 15, documents[4].Line)
+	assert.NoError(t, documents[4].Err)
+	assert.Equal(t, 15, documents[4].Node.Content[0].Line)
+}
+
+func TestSplit_Empty(t *testing.T) {
+	assert.Empty(t, Split(nil))
+	assert.Empty(t, Split([]byte("---\n\n---\n")))
+}
@@ -0,0 +1,139 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+)
+
+// SARIFRuleID is the rule id of every result in the SARIF log written by MarshalSARIF
+const SARIFRuleID = "kotskinds/yaml-error"
+
+// WriteReport writes yamlErrors to w, one per line, in the form
+// `path:line:column: document N: error`. The line and column are left out when they are
+// not known.
+func WriteReport(w io.Writer, yamlErrors []kotsv1beta1.InstallationYAMLError) error {
+	for _, yamlErr := range yamlErrors {
+		location := yamlErr.Path
+		if yamlErr.Line > 0 {
+			location += fmt.Sprintf(":%d", yamlErr.Line)
+			if yamlErr.Column > 0 {
+				location += fmt.Sprintf(":%d", yamlErr.Column)
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s: document %d: %s\n", location, yamlErr.DocumentIndex, yamlErr.Error); err != nil {
+			return errors.Wrap(err, "failed to write report")
+		}
+	}
+	return nil
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations"`
+	Properties map[string]int  `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// MarshalSARIF returns yamlErrors as a SARIF 2.1.0 log, with a result at error level for
+// each error. The document index is kept in the documentIndex property of each result.
+func MarshalSARIF(yamlErrors []kotsv1beta1.InstallationYAMLError) ([]byte, error) {
+	results := []sarifResult{}
+	for _, yamlErr := range yamlErrors {
+		location := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: yamlErr.Path},
+			},
+		}
+		if yamlErr.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{
+				StartLine:   yamlErr.Line,
+				StartColumn: yamlErr.Column,
+			}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:     SARIFRuleID,
+			Level:      "error",
+			Message:    sarifMessage{Text: yamlErr.Error},
+			Locations:  []sarifLocation{location},
+			Properties: map[string]int{"documentIndex": yamlErr.DocumentIndex},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{
+				Driver: sarifDriver{
+					Name:           "kotskinds",
+					InformationURI: "https://github.com/replicatedhq/kotskinds",
+					Rules: []sarifRule{{
+						ID:               SARIFRuleID,
+						ShortDescription: sarifMessage{Text: "Invalid YAML or Kubernetes object in a release"},
+					}},
+				},
+			},
+			Results: results,
+		}},
+	}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal sarif")
+	}
+	return data, nil
+}
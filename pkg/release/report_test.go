@@ -0,0 +1,56 @@
+package release
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testYAMLErrors = []kotsv1beta1.InstallationYAMLError{
+	{Path: "config.yaml", Error: `unknown field "spec.foo"`, Line: 6, Column: 3},
+	{Path: "multi.yaml", Error: "missing kind", DocumentIndex: 2, Line: 9},
+	{Path: "legacy.yaml", Error: "failed to parse"},
+}
+
+func TestWriteReport(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, testYAMLErrors))
+	assert.Equal(t, `config.yaml:6:3: document 0: unknown field "spec.foo"
+multi.yaml:9: document 2: missing kind
+legacy.yaml: document 0: failed to parse
+`, buf.String())
+}
+
+func TestMarshalSARIF(t *testing.T) {
+	data, err := MarshalSARIF(testYAMLErrors)
+	require.NoError(t, err)
+
+	var log map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &log))
+	assert.Equal(t, "2.1.0", log["version"])
+
+	run := log["runs"].([]interface{})[0].(map[string]interface{})
+	driver := run["tool"].(map[string]interface{})["driver"].(map[string]interface{})
+	assert.Equal(t, "kotskinds", driver["name"])
+
+	results := run["results"].([]interface{})
+	require.Len(t, results, 3)
+
+	first := results[0].(map[string]interface{})
+	assert.Equal(t, SARIFRuleID, first["ruleId"])
+	assert.Equal(t, "error", first["level"])
+	physical := first["locations"].([]interface{})[0].(map[string]interface{})["physicalLocation"].(map[string]interface{})
+	assert.Equal(t, "config.yaml", physical["artifactLocation"].(map[string]interface{})["uri"])
+	assert.Equal(t, map[string]interface{}{"startLine": 6.0, "startColumn": 3.0}, physical["region"])
+
+	second := results[1].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"documentIndex": 2.0}, second["properties"])
+
+	third := results[2].(map[string]interface{})
+	physical = third["locations"].([]interface{})[0].(map[string]interface{})["physicalLocation"].(map[string]interface{})
+	assert.NotContains(t, physical, "region")
+}
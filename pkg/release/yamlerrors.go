@@ -0,0 +1,302 @@
+// Package release checks the YAML files of a KOTS release, and reports the errors it finds
+// as Installation YAMLErrors.
+package release
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	kotsscheme "github.com/replicatedhq/kotskinds/client/kotsclientset/scheme"
+	"github.com/replicatedhq/kotskinds/pkg/internal/yamldoc"
+	yaml "go.yaml.in/yaml/v3"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func init() {
+	kotsscheme.AddToScheme(scheme.Scheme)
+}
+
+var (
+	yamlLineRegexp  = regexp.MustCompile(`line (\d+)(?::(\d+))?`)
+	fieldPathRegexp = regexp.MustCompile(`(?:unknown|duplicate) field "([^"]+)"`)
+	goFieldRegexp   = regexp.MustCompile(`Go struct field \w+\.([\w.]+)`)
+)
+
+var strictSerializer = json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, json.SerializerOptions{
+	Yaml:   true,
+	Strict: true,
+})
+
+// ParseDir parses every .yaml and .yml file under dir, and returns the errors found. See
+// ParseFiles. Paths in the errors are relative to dir and use forward slashes.
+func ParseDir(dir string) ([]kotsv1beta1.InstallationYAMLError, error) {
+	files := map[string][]byte{}
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isYAMLFile(p) {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", p)
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get relative path of %s", p)
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to walk release")
+	}
+
+	return ParseFiles(files), nil
+}
+
+// ParseFiles parses each document of each file in files, which maps paths to contents, and
+// returns the errors found, ordered by path and position. A document is in error when it is
+// not valid YAML, or is not a Kubernetes object with an apiVersion and kind. Documents in
+// the kots.io group must also decode strictly into a known kotskinds kind, so unknown or
+// duplicate fields and values of the wrong type are errors too.
+func ParseFiles(files map[string][]byte) []kotsv1beta1.InstallationYAMLError {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	yamlErrors := []kotsv1beta1.InstallationYAMLError{}
+	for _, p := range paths {
+		for _, doc := range yamldoc.Split(files[p]) {
+			if yamlErr := parseDocument(doc); yamlErr != nil {
+				yamlErr.Path = p
+				yamlErrors = append(yamlErrors, *yamlErr)
+			}
+		}
+	}
+	return yamlErrors
+}
+
+// SetYAMLErrors parses the release in dir and sets the errors found as the YAMLErrors of
+// installation
+func SetYAMLErrors(installation *kotsv1beta1.Installation, dir string) error {
+	yamlErrors, err := ParseDir(dir)
+	if err != nil {
+		return err
+	}
+	installation.Spec.YAMLErrors = yamlErrors
+	return nil
+}
+
+func isYAMLFile(p string) bool {
+	ext := strings.ToLower(path.Ext(p))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// parseDocument returns the first error in doc, or nil. Positions in the returned error are
+// relative to the file.
+func parseDocument(doc yamldoc.Document) *kotsv1beta1.InstallationYAMLError {
+	yamlErr := func(message string, line int, column int) *kotsv1beta1.InstallationYAMLError {
+		if line <= 0 {
+			line = doc.Line
+		}
+		return &kotsv1beta1.InstallationYAMLError{
+			Error:         message,
+			DocumentIndex: doc.Index,
+			Line:          line,
+			Column:        column,
+		}
+	}
+
+	if doc.Err != nil {
+		line, column := positionInDocument(doc, doc.Err.Error())
+		return yamlErr(doc.Err.Error(), line, column)
+	}
+	if len(doc.Node.Content) == 0 || isCommentsOnly(doc.Node.Content[0]) {
+		return nil
+	}
+	object := doc.Node.Content[0]
+	if object.Kind != yaml.MappingNode {
+		return yamlErr("document is not a kubernetes object", object.Line, object.Column)
+	}
+
+	apiVersion, kind := mappingValue(object, "apiVersion"), mappingValue(object, "kind")
+	if apiVersion == nil || apiVersion.Value == "" {
+		return yamlErr("missing apiVersion", object.Line, object.Column)
+	}
+	if kind == nil || kind.Value == "" {
+		return yamlErr("missing kind", object.Line, object.Column)
+	}
+
+	gv, err := schema.ParseGroupVersion(apiVersion.Value)
+	if err != nil {
+		return yamlErr(err.Error(), apiVersion.Line, apiVersion.Column)
+	}
+	if gv.Group != kotsv1beta1.SchemeGroupVersion.Group {
+		return nil
+	}
+
+	if _, _, err := strictSerializer.Decode(doc.Data, nil, nil); err != nil {
+		return decodeError(err, doc, object, yamlErr)
+	}
+	return nil
+}
+
+// decodeError returns a yaml error for an error decoding a kots.io object, positioned at the
+// field it is about when that can be found
+func decodeError(err error, doc yamldoc.Document, object *yaml.Node, yamlErr func(string, int, int) *kotsv1beta1.InstallationYAMLError) *kotsv1beta1.InstallationYAMLError {
+	message := err.Error()
+	if strictErr, ok := runtime.AsStrictDecodingError(err); ok && len(strictErr.Errors()) > 0 {
+		message = strictErr.Errors()[0].Error()
+	}
+
+	if match := fieldPathRegexp.FindStringSubmatch(message); match != nil {
+		if node := findField(object, match[1]); node != nil {
+			return yamlErr(message, node.Line, node.Column)
+		}
+	}
+	if match := goFieldRegexp.FindStringSubmatch(message); match != nil {
+		if node := findField(object, match[1]); node != nil {
+			return yamlErr(message, node.Line, node.Column)
+		}
+	}
+	if line, column := positionInDocument(doc, message); line > 0 {
+		return yamlErr(message, line, column)
+	}
+	return yamlErr(message, object.Line, object.Column)
+}
+
+// positionInDocument returns the line of the file and the column in an error message about
+// the data of doc, or zeros
+func positionInDocument(doc yamldoc.Document, message string) (int, int) {
+	line, column := positionInMessage(message)
+	if line > 0 {
+		line += doc.Line - 1
+	}
+	return line, column
+}
+
+// isCommentsOnly returns true if node is the empty value the decoder gives a document that
+// holds only comments
+func isCommentsOnly(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null" && node.Value == ""
+}
+
+// positionInMessage returns the line and column in a yaml error message, or zeros
+func positionInMessage(message string) (int, int) {
+	match := yamlLineRegexp.FindStringSubmatch(message)
+	if match == nil {
+		return 0, 0
+	}
+	line, _ := strconv.Atoi(match[1])
+	column, _ := strconv.Atoi(match[2])
+	return line, column
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// findField returns the key node of the field at fieldPath, such as "spec.groups[0].items",
+// under node. Path segments without an index match the first element of a sequence that
+// has the rest of the path, as in the field paths of json type errors.
+func findField(node *yaml.Node, fieldPath string) *yaml.Node {
+	segments := strings.Split(fieldPath, ".")
+	return findFieldSegments(node, segments)
+}
+
+func findFieldSegments(node *yaml.Node, segments []string) *yaml.Node {
+	if len(segments) == 0 {
+		return nil
+	}
+
+	if node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			if found := findFieldSegments(item, segments); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+
+	name, indexes := splitIndexes(segments[0])
+	key := mappingKey(node, name)
+	if key == nil {
+		return nil
+	}
+	value := mappingValue(node, name)
+	for _, index := range indexes {
+		if value.Kind != yaml.SequenceNode || index >= len(value.Content) {
+			return key
+		}
+		key, value = value.Content[index], value.Content[index]
+	}
+
+	if len(segments) == 1 {
+		return key
+	}
+	if found := findFieldSegments(value, segments[1:]); found != nil {
+		return found
+	}
+	return key
+}
+
+// splitIndexes splits "items[0][1]" into "items" and [0, 1]
+func splitIndexes(segment string) (string, []int) {
+	name, rest, ok := strings.Cut(segment, "[")
+	if !ok {
+		return segment, nil
+	}
+
+	indexes := []int{}
+	for _, part := range strings.Split(rest, "[") {
+		index, err := strconv.Atoi(strings.TrimSuffix(part, "]"))
+		if err != nil {
+			break
+		}
+		indexes = append(indexes, index)
+	}
+	return name, indexes
+}
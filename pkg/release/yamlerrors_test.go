@@ -0,0 +1,117 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFiles(t *testing.T) {
+	files := map[string][]byte{
+		"deployment.yaml": []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: repl{{ ConfigOption "replicas" }}
+`),
+		"config.yaml": []byte(`# the app config
+apiVersion: kots.io/v1beta1
+kind: Config
+metadata:
+  name: config
+spec:
+  groups:
+  - name: settings
+    items:
+    - name: hostname
+      type: text
+      hostnmae: typo
+`),
+		"multi.yaml": []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ok
+---
+# comment only
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: [broken
+---
+metadata:
+  name: no-kind
+`),
+		"application.yml": []byte(`apiVersion: kots.io/v1beta1
+kind: Application
+metadata:
+  name: app
+spec:
+  title: My App
+  statusInformers: deployment/web
+`),
+		"unknown-kind.yaml": []byte(`apiVersion: kots.io/v1beta1
+kind: Nope
+`),
+		"list.yaml": []byte("- a\n- b\n"),
+	}
+
+	yamlErrors := ParseFiles(files)
+	require.Len(t, yamlErrors, 6)
+
+	byPath := map[string][]kotsv1beta1.InstallationYAMLError{}
+	for _, yamlErr := range yamlErrors {
+		byPath[yamlErr.Path] = append(byPath[yamlErr.Path], yamlErr)
+	}
+
+	require.Len(t, byPath["application.yml"], 1)
+	assert.Equal(t, 7, byPath["application.yml"][0].Line)
+	assert.Contains(t, byPath["application.yml"][0].Error, "statusInformers")
+
+	require.Len(t, byPath["config.yaml"], 1)
+	assert.Equal(t, kotsv1beta1.InstallationYAMLError{
+		Path:          "config.yaml",
+		Error:         `unknown field "spec.groups[0].items[0].hostnmae"`,
+		DocumentIndex: 0,
+		Line:          12,
+		Column:        7,
+	}, byPath["config.yaml"][0])
+
+	// the comment only document counts towards the document index
+	require.Len(t, byPath["multi.yaml"], 2)
+	assert.Equal(t, 2, byPath["multi.yaml"][0].DocumentIndex)
+	assert.True(t, strings.HasPrefix(byPath["multi.yaml"][0].Error, "yaml: "))
+	assert.GreaterOrEqual(t, byPath["multi.yaml"][0].Line, 8)
+	assert.Equal(t, 3, byPath["multi.yaml"][1].DocumentIndex)
+	assert.Equal(t, 13, byPath["multi.yaml"][1].Line)
+	assert.Equal(t, "missing apiVersion", byPath["multi.yaml"][1].Error)
+
+	require.Len(t, byPath["unknown-kind.yaml"], 1)
+	assert.Contains(t, byPath["unknown-kind.yaml"][0].Error, `no kind "Nope"`)
+
+	require.Len(t, byPath["list.yaml"], 1)
+	assert.Equal(t, "document is not a kubernetes object", byPath["list.yaml"][0].Error)
+
+	assert.Empty(t, byPath["deployment.yaml"])
+}
+
+func TestSetYAMLErrors(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "manifests"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifests", "bad.yaml"), []byte("kind: [\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "ignored.yaml"), []byte("kind: [\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "good.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\n"), 0644))
+
+	installation := &kotsv1beta1.Installation{}
+	require.NoError(t, SetYAMLErrors(installation, dir))
+	require.Len(t, installation.Spec.YAMLErrors, 1)
+	assert.Equal(t, "manifests/bad.yaml", installation.Spec.YAMLErrors[0].Path)
+	assert.True(t, strings.HasPrefix(installation.Spec.YAMLErrors[0].Error, "yaml: "))
+}
@@ -0,0 +1,22 @@
+package repeatable
+
+import (
+	"errors"
+	"fmt"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+)
+
+// TemplateNotFoundError is returned when no document matches a RepeatTemplate
+type TemplateNotFoundError struct {
+	Template kotsv1beta1.RepeatTemplate
+}
+
+func (e *TemplateNotFoundError) Error() string {
+	return fmt.Sprintf("no document found for %s %s %q in namespace %q", e.Template.APIVersion, e.Template.Kind, e.Template.Name, e.Template.Namespace)
+}
+
+func IsTemplateNotFoundError(err error) bool {
+	var tnfe *TemplateNotFoundError
+	return errors.As(err, &tnfe)
+}
@@ -0,0 +1,255 @@
+// Package repeatable expands repeatable KOTS config items into one concrete item per
+// value, and collapses them back.
+//
+// A repeatable item stores its values in ValuesByGroup, keyed by group name and then by
+// value name. The same values are stored in ConfigValues as entries keyed by value name,
+// with RepeatableItem set to the name of the repeatable item.
+package repeatable
+
+import (
+	"sort"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/multitype"
+)
+
+// Instance is one value of a repeatable item
+type Instance struct {
+	Group string
+	// Item is the name of the repeatable item
+	Item string
+	// Name is the name of the value, which is also its key in ConfigValues
+	Name  string
+	Value string
+}
+
+// Instances returns every value of every repeatable item in config, combining the item's
+// ValuesByGroup with the entries in values that point back to it. Entries in values take
+// precedence. values may be nil. Instances are ordered by group and item, then by name.
+func Instances(config *kotsv1beta1.Config, values *kotsv1beta1.ConfigValues) []Instance {
+	instances := []Instance{}
+	for _, group := range config.Spec.Groups {
+		for _, item := range group.Items {
+			if !item.Repeatable {
+				continue
+			}
+			instances = append(instances, itemInstances(group.Name, item, values)...)
+		}
+	}
+	return instances
+}
+
+func itemInstances(groupName string, item kotsv1beta1.ConfigItem, values *kotsv1beta1.ConfigValues) []Instance {
+	byName := map[string]string{}
+	for name, value := range item.ValuesByGroup[groupName] {
+		byName[name] = value
+	}
+	if values != nil {
+		for name, configValue := range values.Spec.Values {
+			if configValue.RepeatableItem == item.Name {
+				byName[name] = configValueString(configValue)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	instances := make([]Instance, 0, len(names))
+	for _, name := range names {
+		instances = append(instances, Instance{
+			Group: groupName,
+			Item:  item.Name,
+			Name:  name,
+			Value: byName[name],
+		})
+	}
+	return instances
+}
+
+// Expand returns a copy of config where each repeatable item is followed by one concrete,
+// non-repeatable item per value, and a copy of values with an entry for each of them.
+// Concrete items copy the repeatable item's type, title and validation, take the value
+// name as their name and the value as their value. The repeatable item's ValuesByGroup
+// and CountByGroup are updated to match. values may be nil.
+//
+// Concrete items already in config are collapsed first, so expanding an expanded config
+// returns it unchanged. Password values are never copied into a concrete item's Value, or
+// into the Value of an entry in values, which only holds encrypted passwords. They are
+// kept in ValuePlaintext instead.
+func Expand(config *kotsv1beta1.Config, values *kotsv1beta1.ConfigValues) (*kotsv1beta1.Config, *kotsv1beta1.ConfigValues) {
+	expandedConfig := Collapse(config, values)
+	expandedValues := &kotsv1beta1.ConfigValues{}
+	if values != nil {
+		expandedValues = values.DeepCopy()
+	}
+	if expandedValues.Spec.Values == nil {
+		expandedValues.Spec.Values = map[string]kotsv1beta1.ConfigValue{}
+	}
+
+	for g, group := range expandedConfig.Spec.Groups {
+		items := []kotsv1beta1.ConfigItem{}
+		for _, item := range group.Items {
+			if !item.Repeatable {
+				items = append(items, item)
+				continue
+			}
+
+			instances := itemInstances(group.Name, item, values)
+			setGroupValues(&item, group.Name, instances)
+			items = append(items, item)
+
+			for _, instance := range instances {
+				items = append(items, instanceItem(item, instance))
+
+				configValue := expandedValues.Spec.Values[instance.Name]
+				if item.Kind() == kotsv1beta1.ConfigItemKindPassword {
+					if configValue.ValuePlaintext == "" && configValue.Value == "" {
+						configValue.ValuePlaintext = instance.Value
+					}
+				} else if configValue.ValuePlaintext == "" {
+					configValue.Value = instance.Value
+				}
+				configValue.RepeatableItem = item.Name
+				expandedValues.Spec.Values[instance.Name] = configValue
+			}
+		}
+		expandedConfig.Spec.Groups[g].Items = items
+	}
+
+	return expandedConfig, expandedValues
+}
+
+// Collapse reverses Expand. It returns a copy of config where the concrete items of each
+// repeatable item are removed and their values stored in the repeatable item's
+// ValuesByGroup, with CountByGroup updated to match. A concrete item is one named in a
+// repeatable item's ValuesByGroup, or one whose entry in values has RepeatableItem set to a
+// repeatable item in the same group. Values are taken from values first, then from the
+// concrete item, then from ValuesByGroup. values may be nil.
+func Collapse(config *kotsv1beta1.Config, values *kotsv1beta1.ConfigValues) *kotsv1beta1.Config {
+	collapsedConfig := config.DeepCopy()
+
+	configValues := map[string]kotsv1beta1.ConfigValue{}
+	if values != nil && values.Spec.Values != nil {
+		configValues = values.Spec.Values
+	}
+
+	for g, group := range collapsedConfig.Spec.Groups {
+		// the repeatable item each value belongs to, by value name
+		parentOf := map[string]string{}
+		repeatableItems := map[string]bool{}
+		for _, item := range group.Items {
+			if !item.Repeatable {
+				continue
+			}
+			repeatableItems[item.Name] = true
+			for name := range item.ValuesByGroup[group.Name] {
+				parentOf[name] = item.Name
+			}
+		}
+		for name, configValue := range configValues {
+			if repeatableItems[configValue.RepeatableItem] {
+				parentOf[name] = configValue.RepeatableItem
+			}
+		}
+
+		concreteValues := map[string]string{}
+		items := []kotsv1beta1.ConfigItem{}
+		for _, item := range group.Items {
+			if _, ok := parentOf[item.Name]; ok && !item.Repeatable {
+				concreteValues[item.Name] = item.Value.String()
+				continue
+			}
+			items = append(items, item)
+		}
+
+		for i, item := range items {
+			if !item.Repeatable {
+				continue
+			}
+
+			names := []string{}
+			for name, parent := range parentOf {
+				if parent == item.Name {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+
+			instances := []Instance{}
+			for _, name := range names {
+				value := configValueString(configValues[name])
+				if value == "" {
+					if concreteValue, ok := concreteValues[name]; ok {
+						value = concreteValue
+					} else {
+						value = item.ValuesByGroup[group.Name][name]
+					}
+				}
+				instances = append(instances, Instance{
+					Group: group.Name,
+					Item:  item.Name,
+					Name:  name,
+					Value: value,
+				})
+			}
+			setGroupValues(&items[i], group.Name, instances)
+		}
+
+		collapsedConfig.Spec.Groups[g].Items = items
+	}
+
+	return collapsedConfig
+}
+
+// SyncCountByGroup sets CountByGroup to the number of values in each group of ValuesByGroup
+func SyncCountByGroup(item *kotsv1beta1.ConfigItem) {
+	if len(item.ValuesByGroup) == 0 {
+		item.CountByGroup = nil
+		return
+	}
+
+	item.CountByGroup = map[string]int{}
+	for groupName, groupValues := range item.ValuesByGroup {
+		item.CountByGroup[groupName] = len(groupValues)
+	}
+}
+
+func setGroupValues(item *kotsv1beta1.ConfigItem, groupName string, instances []Instance) {
+	if item.ValuesByGroup == nil {
+		item.ValuesByGroup = kotsv1beta1.ValuesByGroup{}
+	}
+
+	groupValues := kotsv1beta1.GroupValues{}
+	for _, instance := range instances {
+		groupValues[instance.Name] = instance.Value
+	}
+	item.ValuesByGroup[groupName] = groupValues
+
+	SyncCountByGroup(item)
+}
+
+func instanceItem(item kotsv1beta1.ConfigItem, instance Instance) kotsv1beta1.ConfigItem {
+	concrete := *item.DeepCopy()
+	concrete.Name = instance.Name
+	concrete.Value = multitype.BoolOrString{}
+	if item.Kind() != kotsv1beta1.ConfigItemKindPassword {
+		concrete.Value = multitype.FromString(instance.Value)
+	}
+	concrete.Repeatable = false
+	concrete.MinimumCount = 0
+	concrete.CountByGroup = nil
+	concrete.ValuesByGroup = nil
+	concrete.Templates = nil
+	return concrete
+}
+
+func configValueString(configValue kotsv1beta1.ConfigValue) string {
+	if configValue.ValuePlaintext != "" {
+		return configValue.ValuePlaintext
+	}
+	return configValue.Value
+}
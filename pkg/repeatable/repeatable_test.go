@@ -0,0 +1,185 @@
+package repeatable
+
+import (
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/multitype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() *kotsv1beta1.Config {
+	return &kotsv1beta1.Config{
+		Spec: kotsv1beta1.ConfigSpec{
+			Groups: []kotsv1beta1.ConfigGroup{
+				{
+					Name: "ports",
+					Items: []kotsv1beta1.ConfigItem{
+						{Name: "title", Type: "label"},
+						{
+							Name:       "port",
+							Type:       "text",
+							Title:      "Port",
+							Repeatable: true,
+							Templates: []kotsv1beta1.RepeatTemplate{
+								{APIVersion: "v1", Kind: "Service", Name: "app", YamlPath: "spec.ports[0]"},
+							},
+							ValuesByGroup: kotsv1beta1.ValuesByGroup{
+								"ports": kotsv1beta1.GroupValues{"port-a": "80"},
+							},
+							CountByGroup: map[string]int{"ports": 5},
+						},
+						{Name: "after", Type: "text"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestInstances(t *testing.T) {
+	values := &kotsv1beta1.ConfigValues{
+		Spec: kotsv1beta1.ConfigValuesSpec{
+			Values: map[string]kotsv1beta1.ConfigValue{
+				"port-a": {Value: "8080", RepeatableItem: "port"},
+				"port-b": {ValuePlaintext: "443", RepeatableItem: "port"},
+				"after":  {Value: "x"},
+			},
+		},
+	}
+
+	assert.Equal(t, []Instance{
+		{Group: "ports", Item: "port", Name: "port-a", Value: "8080"},
+		{Group: "ports", Item: "port", Name: "port-b", Value: "443"},
+	}, Instances(testConfig(), values))
+
+	assert.Equal(t, []Instance{
+		{Group: "ports", Item: "port", Name: "port-a", Value: "80"},
+	}, Instances(testConfig(), nil))
+}
+
+func TestExpandAndCollapse(t *testing.T) {
+	config := testConfig()
+	values := &kotsv1beta1.ConfigValues{
+		Spec: kotsv1beta1.ConfigValuesSpec{
+			Values: map[string]kotsv1beta1.ConfigValue{
+				"port-b": {Value: "443", RepeatableItem: "port"},
+				"after":  {Value: "x"},
+			},
+		},
+	}
+
+	expandedConfig, expandedValues := Expand(config, values)
+
+	items := expandedConfig.Spec.Groups[0].Items
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	assert.Equal(t, []string{"title", "port", "port-a", "port-b", "after"}, names)
+
+	assert.Equal(t, map[string]int{"ports": 2}, items[1].CountByGroup)
+	assert.Equal(t, kotsv1beta1.GroupValues{"port-a": "80", "port-b": "443"}, items[1].ValuesByGroup["ports"])
+
+	assert.Equal(t, "text", items[2].Type)
+	assert.Equal(t, "Port", items[2].Title)
+	assert.Equal(t, multitype.FromString("80"), items[2].Value)
+	assert.False(t, items[2].Repeatable)
+	assert.Nil(t, items[2].Templates)
+	assert.Nil(t, items[2].ValuesByGroup)
+
+	assert.Equal(t, map[string]kotsv1beta1.ConfigValue{
+		"port-a": {Value: "80", RepeatableItem: "port"},
+		"port-b": {Value: "443", RepeatableItem: "port"},
+		"after":  {Value: "x"},
+	}, expandedValues.Spec.Values)
+
+	// inputs are not modified
+	assert.Len(t, config.Spec.Groups[0].Items, 3)
+	assert.Equal(t, map[string]int{"ports": 5}, config.Spec.Groups[0].Items[1].CountByGroup)
+	assert.NotContains(t, values.Spec.Values, "port-a")
+
+	// a new value is added and one is changed before collapsing
+	expandedValues.Spec.Values["port-c"] = kotsv1beta1.ConfigValue{Value: "9000", RepeatableItem: "port"}
+	expandedValues.Spec.Values["port-a"] = kotsv1beta1.ConfigValue{Value: "81", RepeatableItem: "port"}
+
+	collapsed := Collapse(expandedConfig, expandedValues)
+	require.Len(t, collapsed.Spec.Groups[0].Items, 3)
+	port := collapsed.Spec.Groups[0].Items[1]
+	assert.Equal(t, "port", port.Name)
+	assert.Equal(t, kotsv1beta1.GroupValues{"port-a": "81", "port-b": "443", "port-c": "9000"}, port.ValuesByGroup["ports"])
+	assert.Equal(t, map[string]int{"ports": 3}, port.CountByGroup)
+	assert.Equal(t, "after", collapsed.Spec.Groups[0].Items[2].Name)
+}
+
+func TestExpand_Idempotent(t *testing.T) {
+	values := &kotsv1beta1.ConfigValues{
+		Spec: kotsv1beta1.ConfigValuesSpec{
+			Values: map[string]kotsv1beta1.ConfigValue{
+				"port-b": {Value: "443", RepeatableItem: "port"},
+			},
+		},
+	}
+
+	expandedConfig, expandedValues := Expand(testConfig(), values)
+	twiceConfig, twiceValues := Expand(expandedConfig, expandedValues)
+	assert.Equal(t, expandedConfig, twiceConfig)
+	assert.Equal(t, expandedValues, twiceValues)
+}
+
+func TestExpand_Password(t *testing.T) {
+	config := testConfig()
+	config.Spec.Groups[0].Items[1].Type = "password"
+	values := &kotsv1beta1.ConfigValues{
+		Spec: kotsv1beta1.ConfigValuesSpec{
+			Values: map[string]kotsv1beta1.ConfigValue{
+				"port-b": {ValuePlaintext: "secret-b", RepeatableItem: "port"},
+				"port-c": {Value: "ZW5jcnlwdGVk", RepeatableItem: "port"},
+			},
+		},
+	}
+
+	expandedConfig, expandedValues := Expand(config, values)
+
+	items := expandedConfig.Spec.Groups[0].Items
+	require.Len(t, items, 6)
+	for _, item := range items[2:5] {
+		assert.True(t, item.Value.IsEmpty(), item.Name)
+	}
+
+	assert.Equal(t, map[string]kotsv1beta1.ConfigValue{
+		"port-a": {ValuePlaintext: "80", RepeatableItem: "port"},
+		"port-b": {ValuePlaintext: "secret-b", RepeatableItem: "port"},
+		"port-c": {Value: "ZW5jcnlwdGVk", RepeatableItem: "port"},
+	}, expandedValues.Spec.Values)
+
+	twiceConfig, twiceValues := Expand(expandedConfig, expandedValues)
+	assert.Equal(t, expandedConfig, twiceConfig)
+	assert.Equal(t, expandedValues, twiceValues)
+}
+
+func TestCollapse_WithoutValues(t *testing.T) {
+	expandedConfig, _ := Expand(testConfig(), nil)
+
+	collapsed := Collapse(expandedConfig, nil)
+	require.Len(t, collapsed.Spec.Groups[0].Items, 3)
+	assert.Equal(t, kotsv1beta1.GroupValues{"port-a": "80"}, collapsed.Spec.Groups[0].Items[1].ValuesByGroup["ports"])
+	assert.Equal(t, map[string]int{"ports": 1}, collapsed.Spec.Groups[0].Items[1].CountByGroup)
+}
+
+func TestSyncCountByGroup(t *testing.T) {
+	item := &kotsv1beta1.ConfigItem{
+		ValuesByGroup: kotsv1beta1.ValuesByGroup{
+			"a": kotsv1beta1.GroupValues{"x": "1", "y": "2"},
+			"b": kotsv1beta1.GroupValues{},
+		},
+		CountByGroup: map[string]int{"a": 1, "c": 4},
+	}
+	SyncCountByGroup(item)
+	assert.Equal(t, map[string]int{"a": 2, "b": 0}, item.CountByGroup)
+
+	item = &kotsv1beta1.ConfigItem{CountByGroup: map[string]int{"a": 1}}
+	SyncCountByGroup(item)
+	assert.Nil(t, item.CountByGroup)
+}
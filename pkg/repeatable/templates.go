@@ -0,0 +1,153 @@
+package repeatable
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/pkg/internal/yamldoc"
+)
+
+// TemplateTarget is the document a RepeatTemplate refers to
+type TemplateTarget struct {
+	Template kotsv1beta1.RepeatTemplate
+	// Filename is the key of the file containing the document
+	Filename string
+	// DocumentIndex is the zero-based index of the document in the file, not counting
+	// empty documents, as in pkg/release and pkg/helmchart
+	DocumentIndex int
+	// Document is the decoded document
+	Document map[string]interface{}
+	// Value is the value found at the template's YamlPath, or the whole document when
+	// YamlPath is empty
+	Value interface{}
+}
+
+// LocateTemplate finds the document named by template in files, which maps filenames to
+// their, possibly multi-document, YAML contents. A document matches when its apiVersion,
+// kind and metadata.name are equal to the template's, and its metadata.namespace is equal
+// to the template's Namespace when one is set. Files are searched in filename order, and
+// the first match is returned. Documents that are not valid YAML, or are not a map, are
+// skipped, and the other documents in their file are still searched.
+//
+// YamlPath is a dot separated path with optional list indexes, such as "spec.ports[0]".
+func LocateTemplate(template kotsv1beta1.RepeatTemplate, files map[string][]byte) (*TemplateTarget, error) {
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		for _, doc := range yamldoc.Split(files[filename]) {
+			if doc.Err != nil {
+				continue
+			}
+			var document map[string]interface{}
+			if err := doc.Node.Decode(&document); err != nil || document == nil {
+				continue
+			}
+			if !matchesTemplate(template, document) {
+				continue
+			}
+
+			value, err := valueAtPath(document, template.YamlPath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to find yaml path %q in %s document %d", template.YamlPath, filename, doc.Index)
+			}
+
+			return &TemplateTarget{
+				Template:      template,
+				Filename:      filename,
+				DocumentIndex: doc.Index,
+				Document:      document,
+				Value:         value,
+			}, nil
+		}
+	}
+
+	return nil, &TemplateNotFoundError{Template: template}
+}
+
+// LocateTemplates finds the document for each of item's templates, in order
+func LocateTemplates(item kotsv1beta1.ConfigItem, files map[string][]byte) ([]TemplateTarget, error) {
+	targets := []TemplateTarget{}
+	for _, template := range item.Templates {
+		target, err := LocateTemplate(template, files)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to locate template for item %s", item.Name)
+		}
+		targets = append(targets, *target)
+	}
+	return targets, nil
+}
+
+func matchesTemplate(template kotsv1beta1.RepeatTemplate, document map[string]interface{}) bool {
+	if document["apiVersion"] != template.APIVersion || document["kind"] != template.Kind {
+		return false
+	}
+
+	metadata, _ := document["metadata"].(map[string]interface{})
+	if metadata["name"] != template.Name {
+		return false
+	}
+	if template.Namespace != "" && metadata["namespace"] != template.Namespace {
+		return false
+	}
+
+	return true
+}
+
+func valueAtPath(document map[string]interface{}, yamlPath string) (interface{}, error) {
+	var current interface{} = document
+	if yamlPath == "" {
+		return current, nil
+	}
+
+	for _, segment := range strings.Split(yamlPath, ".") {
+		key := segment
+		indexes := []int{}
+		if open := strings.Index(segment, "["); open != -1 {
+			key = segment[:open]
+			for _, part := range strings.Split(segment[open:], "]") {
+				if part == "" {
+					continue
+				}
+				if !strings.HasPrefix(part, "[") {
+					return nil, errors.Errorf("invalid path segment %q", segment)
+				}
+				index, err := strconv.Atoi(part[1:])
+				if err != nil {
+					return nil, errors.Errorf("invalid index in path segment %q", segment)
+				}
+				indexes = append(indexes, index)
+			}
+		}
+
+		if key != "" {
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("%q is not a map", key)
+			}
+			current, ok = m[key]
+			if !ok {
+				return nil, errors.Errorf("key %q not found", key)
+			}
+		}
+
+		for _, index := range indexes {
+			list, ok := current.([]interface{})
+			if !ok {
+				return nil, errors.Errorf("%q is not a list", segment)
+			}
+			if index < 0 || index >= len(list) {
+				return nil, errors.Errorf("index %d out of range in %q", index, segment)
+			}
+			current = list[index]
+		}
+	}
+
+	return current, nil
+}
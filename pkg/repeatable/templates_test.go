@@ -0,0 +1,115 @@
+package repeatable
+
+import (
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFiles = map[string][]byte{
+	"deployment.yaml": []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+`),
+	"services.yaml": []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: other
+spec:
+  ports: []
+---
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: default
+spec:
+  ports:
+    - name: http
+      port: 80
+`),
+	"invalid.yaml": []byte(`: not yaml: [`),
+	"mixed.yaml": []byte(`# comment only
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: broken
+data: [
+---
+- a list
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+`),
+}
+
+func TestLocateTemplate(t *testing.T) {
+	target, err := LocateTemplate(kotsv1beta1.RepeatTemplate{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Name:       "app",
+		Namespace:  "default",
+		YamlPath:   "spec.ports[0]",
+	}, testFiles)
+	require.NoError(t, err)
+	assert.Equal(t, "services.yaml", target.Filename)
+	assert.Equal(t, 1, target.DocumentIndex)
+	assert.Equal(t, map[string]interface{}{"name": "http", "port": 80}, target.Value)
+
+	target, err = LocateTemplate(kotsv1beta1.RepeatTemplate{APIVersion: "apps/v1", Kind: "Deployment", Name: "app"}, testFiles)
+	require.NoError(t, err)
+	assert.Equal(t, "deployment.yaml", target.Filename)
+	assert.Equal(t, target.Document, target.Value)
+
+	// namespace is optional
+	target, err = LocateTemplate(kotsv1beta1.RepeatTemplate{APIVersion: "v1", Kind: "Service", Name: "app"}, testFiles)
+	require.NoError(t, err)
+	assert.Equal(t, 0, target.DocumentIndex)
+}
+
+func TestLocateTemplate_InvalidDocument(t *testing.T) {
+	// the broken and list documents are skipped, not the whole file, and the index counts
+	// them like pkg/release does
+	target, err := LocateTemplate(kotsv1beta1.RepeatTemplate{APIVersion: "v1", Kind: "Secret", Name: "app"}, testFiles)
+	require.NoError(t, err)
+	assert.Equal(t, "mixed.yaml", target.Filename)
+	assert.Equal(t, 3, target.DocumentIndex)
+}
+
+func TestLocateTemplate_Errors(t *testing.T) {
+	_, err := LocateTemplate(kotsv1beta1.RepeatTemplate{APIVersion: "v1", Kind: "Service", Name: "missing"}, testFiles)
+	require.Error(t, err)
+	assert.True(t, IsTemplateNotFoundError(err))
+
+	_, err = LocateTemplate(kotsv1beta1.RepeatTemplate{APIVersion: "v1", Kind: "Service", Name: "app", Namespace: "other", YamlPath: "spec.ports[0]"}, testFiles)
+	require.Error(t, err)
+	assert.False(t, IsTemplateNotFoundError(err))
+}
+
+func TestLocateTemplates(t *testing.T) {
+	item := kotsv1beta1.ConfigItem{
+		Name: "port",
+		Templates: []kotsv1beta1.RepeatTemplate{
+			{APIVersion: "v1", Kind: "Service", Name: "app", Namespace: "default", YamlPath: "spec.ports"},
+			{APIVersion: "v1", Kind: "Service", Name: "missing"},
+		},
+	}
+
+	_, err := LocateTemplates(item, testFiles)
+	require.Error(t, err)
+	assert.True(t, IsTemplateNotFoundError(err))
+
+	item.Templates = item.Templates[:1]
+	targets, err := LocateTemplates(item, testFiles)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Len(t, targets[0].Value, 1)
+}
@@ -0,0 +1,153 @@
+// Package repltemplate statically analyzes KOTS repl templates, such as
+// `repl{{ ConfigOption "hostname" }}` or `{{repl LicenseFieldValue "seats" }}`, without
+// rendering them.
+package repltemplate
+
+import (
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	leftDelim  = "{{repl"
+	rightDelim = "}}"
+)
+
+// configItemFuncs are the template functions that take a config item name as their first argument
+var configItemFuncs = map[string]bool{
+	"ConfigOption":          true,
+	"ConfigOptionData":      true,
+	"ConfigOptionFilename":  true,
+	"ConfigOptionEquals":    true,
+	"ConfigOptionNotEquals": true,
+}
+
+// licenseFieldFuncs are the template functions that take a license field name as their first argument
+var licenseFieldFuncs = map[string]bool{
+	"LicenseFieldValue": true,
+}
+
+// Analysis describes what a repl template references. All lists are sorted and de-duplicated.
+type Analysis struct {
+	// Functions are the template functions called, including pipeline functions such as nindent
+	Functions []string
+	// ConfigItems are the config item names passed as string literals to ConfigOption and
+	// related functions
+	ConfigItems []string
+	// LicenseFields are the license field names passed as string literals to LicenseFieldValue
+	LicenseFields []string
+}
+
+// IsTemplate reports whether a string contains a repl template
+func IsTemplate(text string) bool {
+	return strings.Contains(text, "repl{{") || strings.Contains(text, "{{repl")
+}
+
+// Analyze parses the repl templates in text and returns the functions, config items and
+// license fields they reference. Text outside of repl delimiters, including plain `{{ }}`
+// helm templates, is ignored. Function names are not checked, so templates calling
+// functions unknown to this package still parse.
+func Analyze(text string) (*Analysis, error) {
+	tree := parse.New("repl")
+	tree.Mode = parse.SkipFuncCheck
+	// both delimiter styles are rendered the same way by kots
+	normalized := strings.ReplaceAll(text, "repl{{", leftDelim)
+	if _, err := tree.Parse(normalized, leftDelim, rightDelim, map[string]*parse.Tree{}); err != nil {
+		return nil, errors.Wrap(err, "failed to parse template")
+	}
+
+	collector := &collector{
+		functions:     map[string]bool{},
+		configItems:   map[string]bool{},
+		licenseFields: map[string]bool{},
+	}
+	collector.walk(tree.Root)
+
+	return &Analysis{
+		Functions:     sortedKeys(collector.functions),
+		ConfigItems:   sortedKeys(collector.configItems),
+		LicenseFields: sortedKeys(collector.licenseFields),
+	}, nil
+}
+
+type collector struct {
+	functions     map[string]bool
+	configItems   map[string]bool
+	licenseFields map[string]bool
+}
+
+func (c *collector) walk(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child)
+		}
+	case *parse.ActionNode:
+		c.walk(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			c.walk(cmd)
+		}
+	case *parse.CommandNode:
+		c.walkCommand(n)
+	case *parse.ChainNode:
+		c.walk(n.Node)
+	case *parse.IfNode:
+		c.walkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		c.walkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		c.walkBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		c.walk(n.Pipe)
+	}
+}
+
+func (c *collector) walkBranch(n *parse.BranchNode) {
+	c.walk(n.Pipe)
+	c.walk(n.List)
+	c.walk(n.ElseList)
+}
+
+func (c *collector) walkCommand(n *parse.CommandNode) {
+	if len(n.Args) == 0 {
+		return
+	}
+
+	if identifier, ok := n.Args[0].(*parse.IdentifierNode); ok {
+		c.functions[identifier.Ident] = true
+
+		if len(n.Args) > 1 {
+			if name, ok := n.Args[1].(*parse.StringNode); ok {
+				if configItemFuncs[identifier.Ident] {
+					c.configItems[name.Text] = true
+				}
+				if licenseFieldFuncs[identifier.Ident] {
+					c.licenseFields[name.Text] = true
+				}
+			}
+		}
+	}
+
+	for _, arg := range n.Args {
+		c.walk(arg)
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,82 @@
+package repltemplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTemplate(t *testing.T) {
+	assert.True(t, IsTemplate(`repl{{ ConfigOption "a" }}`))
+	assert.True(t, IsTemplate(`prefix-{{repl ConfigOption "a" }}`))
+	assert.False(t, IsTemplate(`{{ .Values.a }}`))
+	assert.False(t, IsTemplate("plain"))
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected *Analysis
+	}{
+		{
+			name: "config option",
+			text: `repl{{ ConfigOption "hostname" }}`,
+			expected: &Analysis{
+				Functions:     []string{"ConfigOption"},
+				ConfigItems:   []string{"hostname"},
+				LicenseFields: []string{},
+			},
+		},
+		{
+			name: "both delimiters, pipelines and nested calls",
+			text: `{{repl ConfigOptionEquals "tls" "1" }}-repl{{ LicenseFieldValue "seats" | nindent 2 }}-{{repl print (ConfigOptionData "cert") }}`,
+			expected: &Analysis{
+				Functions:     []string{"ConfigOptionData", "ConfigOptionEquals", "LicenseFieldValue", "nindent", "print"},
+				ConfigItems:   []string{"cert", "tls"},
+				LicenseFields: []string{"seats"},
+			},
+		},
+		{
+			name: "control structures",
+			text: `{{repl if HasLocalRegistry }}{{repl LocalRegistryHost }}{{repl else }}{{repl ConfigOption "registry" }}{{repl end }}`,
+			expected: &Analysis{
+				Functions:     []string{"ConfigOption", "HasLocalRegistry", "LocalRegistryHost"},
+				ConfigItems:   []string{"registry"},
+				LicenseFields: []string{},
+			},
+		},
+		{
+			name: "helm templates are ignored",
+			text: `{{ .Values.a }} repl{{ ConfigOption "a" }} {{ ConfigOption "a" }}`,
+			expected: &Analysis{
+				Functions:     []string{"ConfigOption"},
+				ConfigItems:   []string{"a"},
+				LicenseFields: []string{},
+			},
+		},
+		{
+			name: "non literal names are not reported",
+			text: `repl{{ ConfigOption (print "a" "b") }}`,
+			expected: &Analysis{
+				Functions:     []string{"ConfigOption", "print"},
+				ConfigItems:   []string{},
+				LicenseFields: []string{},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			analysis, err := Analyze(test.text)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, analysis)
+		})
+	}
+}
+
+func TestAnalyze_Invalid(t *testing.T) {
+	_, err := Analyze(`repl{{ ConfigOption "a" `)
+	require.Error(t, err)
+}
@@ -0,0 +1,24 @@
+package version
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// IncomparableError is returned when two versions can't be ordered, because their labels
+// are not semver or semver is not required, and their cursors are not numbers
+type IncomparableError struct {
+	A      Version
+	B      Version
+	Reason string
+}
+
+func (e *IncomparableError) Error() string {
+	return fmt.Sprintf("can't compare %s with %s: %s", e.A, e.B, e.Reason)
+}
+
+func IsIncomparableError(err error) bool {
+	var ie *IncomparableError
+	return errors.As(err, &ie)
+}
@@ -0,0 +1,149 @@
+// Package version decides which of two releases is newer, from the version labels and
+// update cursors held by Installation and Airgap.
+package version
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/pkg/licensewrapper"
+)
+
+// Version identifies a release by its version label and update cursor
+type Version struct {
+	Label  string
+	Cursor string
+}
+
+// FromInstallation returns the version of the release installed by installation
+func FromInstallation(installation *kotsv1beta1.Installation) Version {
+	return Version{Label: installation.Spec.VersionLabel, Cursor: installation.Spec.UpdateCursor}
+}
+
+// FromReleaseMeta returns the version of an airgap release, such as an Airgap spec or one
+// of its required releases
+func FromReleaseMeta(release kotsv1beta1.AirgapReleaseMeta) Version {
+	return Version{Label: release.VersionLabel, Cursor: release.UpdateCursor}
+}
+
+func (v Version) String() string {
+	switch {
+	case v.Label != "" && v.Cursor != "":
+		return fmt.Sprintf("%s (cursor %s)", v.Label, v.Cursor)
+	case v.Label != "":
+		return v.Label
+	default:
+		return fmt.Sprintf("cursor %s", v.Cursor)
+	}
+}
+
+// IsEmpty returns true if v has neither a label nor a cursor
+func (v Version) IsEmpty() bool {
+	return v.Label == "" && v.Cursor == ""
+}
+
+// Comparator compares versions of the releases of a channel
+type Comparator struct {
+	// SemverRequired compares by version label first, as the channel requires semver labels
+	SemverRequired bool
+}
+
+// NewComparator returns a Comparator for the channel channelID of license. Semver is
+// required when the license requires it, or when the channel does.
+func NewComparator(license licensewrapper.LicenseWrapper, channelID string) Comparator {
+	return Comparator{SemverRequired: IsSemverRequired(license, channelID)}
+}
+
+// IsSemverRequired returns true if the license, or its channel channelID, requires semver
+// version labels
+func IsSemverRequired(license licensewrapper.LicenseWrapper, channelID string) bool {
+	if license.IsSemverRequired() {
+		return true
+	}
+	for _, channel := range license.GetChannels() {
+		if channel.ChannelID == channelID {
+			return channel.IsSemverRequired
+		}
+	}
+	return false
+}
+
+// Compare returns -1, 0 or 1 as a is older than, the same as or newer than b.
+//
+// When SemverRequired is set and both labels are semver, with an optional "v" prefix, they
+// are compared as semver, with prereleases older than their release. Releases with equal
+// labels are then ordered by cursor when both cursors are numbers. Otherwise the numeric
+// update cursors are compared. An *IncomparableError is returned when neither works.
+func (c Comparator) Compare(a, b Version) (int, error) {
+	if c.SemverRequired {
+		if cmp, ok := compareSemver(a.Label, b.Label); ok {
+			if cmp == 0 {
+				if cursorCmp, err := compareCursors(a.Cursor, b.Cursor); err == nil {
+					return cursorCmp, nil
+				}
+			}
+			return cmp, nil
+		}
+	}
+
+	cmp, err := compareCursors(a.Cursor, b.Cursor)
+	if err != nil {
+		reason := err.Error()
+		if c.SemverRequired {
+			reason = fmt.Sprintf("labels are not both semver and %s", reason)
+		}
+		return 0, &IncomparableError{A: a, B: b, Reason: reason}
+	}
+	return cmp, nil
+}
+
+// IsNewer returns true if a is newer than b. An empty b, such as the version of an app that
+// is not installed yet, is older than every version.
+func (c Comparator) IsNewer(a, b Version) (bool, error) {
+	if b.IsEmpty() {
+		return !a.IsEmpty(), nil
+	}
+	cmp, err := c.Compare(a, b)
+	if err != nil {
+		return false, err
+	}
+	return cmp > 0, nil
+}
+
+// Compare compares a and b, see Comparator.Compare
+func Compare(a, b Version, semverRequired bool) (int, error) {
+	return Comparator{SemverRequired: semverRequired}.Compare(a, b)
+}
+
+func compareSemver(a, b string) (int, bool) {
+	av, err := semver.NewVersion(a)
+	if err != nil {
+		return 0, false
+	}
+	bv, err := semver.NewVersion(b)
+	if err != nil {
+		return 0, false
+	}
+	return av.Compare(bv), true
+}
+
+func compareCursors(a, b string) (int, error) {
+	ac, err := strconv.ParseInt(a, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("cursor %q is not a number", a)
+	}
+	bc, err := strconv.ParseInt(b, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("cursor %q is not a number", b)
+	}
+	switch {
+	case ac < bc:
+		return -1, nil
+	case ac > bc:
+		return 1, nil
+	}
+	return 0, nil
+}
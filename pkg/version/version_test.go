@@ -0,0 +1,148 @@
+package version
+
+import (
+	"testing"
+
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/replicatedhq/kotskinds/pkg/licensewrapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name           string
+		a              Version
+		b              Version
+		semverRequired bool
+		want           int
+		wantErr        bool
+	}{
+		{
+			name:           "semver",
+			a:              Version{Label: "1.10.0", Cursor: "1"},
+			b:              Version{Label: "1.9.0", Cursor: "2"},
+			semverRequired: true,
+			want:           1,
+		},
+		{
+			name:           "semver with v prefix",
+			a:              Version{Label: "v1.2.0"},
+			b:              Version{Label: "1.2.1"},
+			semverRequired: true,
+			want:           -1,
+		},
+		{
+			name:           "prerelease is older than release",
+			a:              Version{Label: "2.0.0-beta.2", Cursor: "5"},
+			b:              Version{Label: "2.0.0", Cursor: "4"},
+			semverRequired: true,
+			want:           -1,
+		},
+		{
+			name:           "prereleases",
+			a:              Version{Label: "2.0.0-beta.10"},
+			b:              Version{Label: "2.0.0-beta.9"},
+			semverRequired: true,
+			want:           1,
+		},
+		{
+			name:           "equal labels ordered by cursor",
+			a:              Version{Label: "1.0.0", Cursor: "3"},
+			b:              Version{Label: "1.0.0", Cursor: "7"},
+			semverRequired: true,
+			want:           -1,
+		},
+		{
+			name:           "equal labels without cursors",
+			a:              Version{Label: "1.0.0"},
+			b:              Version{Label: "v1.0.0"},
+			semverRequired: true,
+			want:           0,
+		},
+		{
+			name:           "semver not required uses cursor",
+			a:              Version{Label: "1.10.0", Cursor: "1"},
+			b:              Version{Label: "1.9.0", Cursor: "2"},
+			semverRequired: false,
+			want:           -1,
+		},
+		{
+			name:           "non semver label falls back to cursor",
+			a:              Version{Label: "latest", Cursor: "12"},
+			b:              Version{Label: "1.0.0", Cursor: "9"},
+			semverRequired: true,
+			want:           1,
+		},
+		{
+			name:           "incomparable",
+			a:              Version{Label: "latest", Cursor: "12"},
+			b:              Version{Label: "1.0.0", Cursor: "abc"},
+			semverRequired: true,
+			wantErr:        true,
+		},
+		{
+			name:    "missing cursor",
+			a:       Version{Label: "1.0.0"},
+			b:       Version{Label: "1.0.1", Cursor: "2"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Compare(test.a, test.b, test.semverRequired)
+			if test.wantErr {
+				require.Error(t, err)
+				assert.True(t, IsIncomparableError(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestComparatorIsNewer(t *testing.T) {
+	c := Comparator{}
+
+	newer, err := c.IsNewer(Version{Cursor: "1"}, Version{})
+	require.NoError(t, err)
+	assert.True(t, newer)
+
+	newer, err = c.IsNewer(Version{Cursor: "1"}, Version{Cursor: "1"})
+	require.NoError(t, err)
+	assert.False(t, newer)
+
+	_, err = c.IsNewer(Version{Cursor: "x"}, Version{Cursor: "1"})
+	assert.True(t, IsIncomparableError(err))
+}
+
+func TestIsSemverRequired(t *testing.T) {
+	license := licensewrapper.LicenseWrapper{
+		V1: &kotsv1beta1.License{
+			Spec: kotsv1beta1.LicenseSpec{
+				Channels: []kotsv1beta1.Channel{
+					{ChannelID: "stable"},
+					{ChannelID: "beta", IsSemverRequired: true},
+				},
+			},
+		},
+	}
+	assert.False(t, IsSemverRequired(license, "stable"))
+	assert.True(t, IsSemverRequired(license, "beta"))
+	assert.False(t, IsSemverRequired(license, "unknown"))
+
+	license.V1.Spec.IsSemverRequired = true
+	assert.True(t, NewComparator(license, "stable").SemverRequired)
+}
+
+func TestFromInstallation(t *testing.T) {
+	installation := &kotsv1beta1.Installation{
+		Spec: kotsv1beta1.InstallationSpec{VersionLabel: "1.2.3", UpdateCursor: "4"},
+	}
+	v := FromInstallation(installation)
+	assert.Equal(t, Version{Label: "1.2.3", Cursor: "4"}, v)
+	assert.Equal(t, "1.2.3 (cursor 4)", v.String())
+	assert.Equal(t, v, FromReleaseMeta(kotsv1beta1.AirgapReleaseMeta{VersionLabel: "1.2.3", UpdateCursor: "4"}))
+}